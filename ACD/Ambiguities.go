@@ -0,0 +1,115 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+// ambiguityCandidate is a command (and condition) that can be detected from a main word, for the ambiguities reports.
+type ambiguityCandidate struct {
+	candidate DetectionCandidate
+	// words_found is the number of words found for the condition (check conditionMatch.wordsFound())
+	words_found int
+}
+
+/*
+mainWordCandidates gets all the commands and conditions that can be detected from a main word on the sentence as it is,
+with the most words found by each command (all the tied conditions of each command).
+
+It's called before any of the commands triggered by the main word takes the words it used from the sentence (with
+'invalidate_detec_words'), so that commands needing the same words are all candidates.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmds_set – same as in sentenceCmdsDetector()
+  - sentence – same as in sentenceCmdsDetector()
+  - words_origins – same as in sentenceCmdsDetector()
+  - main_word_index – the index on the sentence of the (first word of the) main word
+  - main_word_match – the main word
+
+– Returns:
+  - the candidates
+*/
+func mainWordCandidates(cmds_set *cmdsSet, sentence []string, words_origins []wordOrigin, main_word_index int,
+	main_word_match mainWordMatch) []ambiguityCandidate {
+	var candidates []ambiguityCandidate = nil
+	for _, i := range main_word_match.cmds_indexes {
+		var cmd commandInfo = cmds_set.cmds[i]
+		var results []conditionMatch = wordsVerificationFunction(sentence, main_word_index, main_word_match.end_index,
+			cmd)
+		for _, condition := range bestMainWordsRetConds(results, main_word_match.main_word, cmd) {
+			var words []string = nil
+			for _, word_match := range results[condition].words_matches {
+				if word_match.index >= 0 {
+					words = append(words, word_match.word)
+				}
+			}
+			candidates = append(candidates, ambiguityCandidate{
+				candidate: DetectionCandidate{
+					Cmd_id:         cmd.cmd_id,
+					Sub_cmd_index:  cmd.variants[condition],
+					Words:          words,
+					Main_word_span: wordsSpan(words_origins, main_word_index, main_word_match.end_index),
+					Words_spans:    matchedWordsSpans(words_origins, results[condition]),
+				},
+				words_found: results[condition].wordsFound(),
+			})
+		}
+	}
+
+	return candidates
+}
+
+/*
+tiedCandidates gets the candidates tied with a detection: the ones with as many words found as it.
+
+-----------------------------------------------------------
+
+– Params:
+  - candidates – the candidates of the main word of the detection, from mainWordCandidates()
+  - detected_cmd – the detection
+  - words_found – the number of words found for the detection
+
+– Returns:
+  - the tied candidates, with the detection first, or nil if the detection is not tied with any other
+*/
+func tiedCandidates(candidates []ambiguityCandidate, detected_cmd detectedCmd, words_found int) []DetectionCandidate {
+	var tied []DetectionCandidate = nil
+	var detection_index int = -1
+	for _, candidate := range candidates {
+		if candidate.words_found == words_found {
+			if candidate.candidate.Cmd_id == detected_cmd.cmd_id &&
+				candidate.candidate.Sub_cmd_index == detected_cmd.sub_cmd_index {
+				detection_index = len(tied)
+			}
+			tied = append(tied, candidate.candidate)
+		}
+	}
+	if len(tied) < 2 || detection_index == -1 {
+		return nil
+	}
+
+	// The others stay in order.
+	var detection DetectionCandidate = tied[detection_index]
+	copy(tied[1:detection_index+1], tied[:detection_index])
+	tied[0] = detection
+
+	return tied
+}
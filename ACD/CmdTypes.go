@@ -0,0 +1,430 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CMD_TYPE_EXPAND_GROUPS is the expansion of a command type in which the follow-up groups are added to all the
+// conditions of the command, without creating new variants ("shut" + "down/off" + "phone").
+const CMD_TYPE_EXPAND_GROUPS string = "groups"
+// CMD_TYPE_EXPAND_VARIANTS is the expansion of a command type in which each word of the follow-up groups creates new
+// variants of the command ("turn" + "on" + "wifi" and "turn" + "off" + "wifi"). With more than one follow-up group,
+// there's a variant for each combination of their words.
+const CMD_TYPE_EXPAND_VARIANTS string = "variants"
+// CMD_TYPE_EXPAND_TRIGGERS is the expansion of a command type in which each trigger word creates new variants of the
+// command, which are only detected with that trigger word ("lock" + "door" and "unlock" + "door"). The follow-up groups
+// are added to all the conditions, as with CMD_TYPE_EXPAND_GROUPS.
+const CMD_TYPE_EXPAND_TRIGGERS string = "triggers"
+
+/*
+CmdType is a type of commands: the words that all the commands of the type have in common, and how the commands are
+expanded with them.
+
+For example, the "turn_onff" type has the trigger words "turn", "get", "switch" and "put", the follow-up group "on/off",
+and is expanded into variants. So a command of this type with the words list "wifi" has the variants "turn on wifi"
+(1st) and "turn off wifi" (2nd) - and with "wifi|airplane mode", "turn on wifi", "turn on airplane mode", "turn off
+wifi" and "turn off airplane mode", in this order.
+
+The built-in types are the CMDi_TYPE_-started constants. More types can be registered with Detector.RegisterCmdType().
+*/
+type CmdType struct {
+	// Name is the name used on the commands to refer to the type. Only lowercase letters, digits and "_" are allowed, and
+	// it can't be only digits (those are the CMDi_TYPE_-started constants).
+	Name string
+	// Trigger_words are the words that trigger the detection of the commands of the type (added to their main words).
+	// They can be phrases too, like the main words ("what_is").
+	Trigger_words []string
+	// Follow_up_groups are the words groups that follow the trigger words (in the same syntax as the words groups of the
+	// conditions - check parseWordsGroup())
+	Follow_up_groups []string
+	// Expansion is how the commands of the type are expanded with the follow-up groups and trigger words (one of the
+	// CMD_TYPE_EXPAND_-started constants)
+	Expansion string
+}
+
+// builtin_cmd_types are the command types that exist on all detectors. The index of each one is the value of its
+// CMDi_TYPE_-started constant, which can also be used as its name.
+var builtin_cmd_types = [...]CmdType{
+	{ // 0 - CMDi_TYPE_NONE
+		Name:      "none",
+		Expansion: CMD_TYPE_EXPAND_GROUPS,
+	},
+	{ // 1 - CMDi_TYPE_TURN_ONFF
+		Name:             "turn_onff",
+		Trigger_words:    []string{"turn", "get", "switch", "put"},
+		Follow_up_groups: []string{"on/off"}, // turn... what? something. what? on or off
+		Expansion:        CMD_TYPE_EXPAND_VARIANTS,
+	},
+	{ // 2 - CMDi_TYPE_ASK
+		Name:          "ask",
+		Trigger_words: []string{"what's", "what", "tell", "say", "how", "how's", "how're"},
+		Expansion:     CMD_TYPE_EXPAND_GROUPS,
+	},
+	{ // 3 - CMDi_TYPE_STOP
+		Name:          "stop",
+		Trigger_words: []string{"stop", "end", "finish", "cease", "conclude", "terminate"},
+		Expansion:     CMD_TYPE_EXPAND_GROUPS,
+	},
+	{ // 4 - CMDi_TYPE_ANSWER
+		Name:          "answer",
+		Trigger_words: []string{"answer", "reply", "respond", "acknowledge"},
+		Expansion:     CMD_TYPE_EXPAND_GROUPS,
+	},
+	{ // 5 - CMDi_TYPE_SHUT_DOWN
+		Name:             "shut_down",
+		Trigger_words:    []string{"shut", "power"},
+		Follow_up_groups: []string{"down/off"},
+		Expansion:        CMD_TYPE_EXPAND_GROUPS,
+	},
+	{ // 6 - CMDi_TYPE_REBOOT
+		Name:          "reboot",
+		Trigger_words: []string{"reboot", "restart"},
+		Expansion:     CMD_TYPE_EXPAND_GROUPS,
+	},
+	{ // 7 - CMDi_TYPE_REPEAT_SPEECH
+		Name:          "repeat_speech",
+		Trigger_words: []string{"what", "say", "come", "go", "repeat"},
+		Expansion:     CMD_TYPE_EXPAND_GROUPS,
+	},
+	{ // 8 - CMDi_TYPE_START
+		Name:          "start",
+		Trigger_words: []string{"start", "begin", "initialize", "commence"},
+		Expansion:     CMD_TYPE_EXPAND_GROUPS,
+	},
+	{ // 9 - CMDi_TYPE_WILL_GO
+		Name:          "will_go",
+		Trigger_words: []string{"will", "gonna", "going", "i'll"},
+		Expansion:     CMD_TYPE_EXPAND_GROUPS,
+	},
+}
+
+// builtin_cmd_types_map_GL has the built-in types by name and by the number of their CMDi_TYPE_-started constant. It's
+// the types map of all new detectors (never modified - registering a type creates a new map).
+var builtin_cmd_types_map_GL map[string]*CmdType = func() map[string]*CmdType {
+	var cmd_types map[string]*CmdType = make(map[string]*CmdType, 2*len(builtin_cmd_types))
+	for i := range builtin_cmd_types {
+		cmd_types[builtin_cmd_types[i].Name] = &builtin_cmd_types[i]
+		cmd_types[strconv.Itoa(i)] = &builtin_cmd_types[i]
+	}
+
+	return cmd_types
+}()
+
+/*
+RegisterCmdType calls Detector.RegisterCmdType() on the default detector.
+*/
+func RegisterCmdType(cmd_type CmdType) error {
+	return default_detector_GL.RegisterCmdType(cmd_type)
+}
+
+/*
+RegisterCmdTypeStr calls Detector.RegisterCmdTypeStr() on the default detector.
+*/
+func RegisterCmdTypeStr(cmd_type_str string) error {
+	return default_detector_GL.RegisterCmdTypeStr(cmd_type_str)
+}
+
+/*
+RegisterCmdType registers a new command type on the detector, which can then be used on the commands by its name.
+
+Registering a type with the name of one already registered replaces it (the built-in types can't be replaced). The
+commands already loaded keep using the type as it was when they were loaded.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd_type – the command type
+
+– Returns:
+  - nil if the type was registered, or an error with all the problems found on the type otherwise
+*/
+func (detector *Detector) RegisterCmdType(cmd_type CmdType) error {
+	if err := validateCmdType(cmd_type); err != nil {
+		return err
+	}
+
+	// Keep a copy, so that the caller can't change it anymore.
+	var new_cmd_type CmdType = CmdType{
+		Name:             cmd_type.Name,
+		Trigger_words:    CopyOuterSLICES(cmd_type.Trigger_words),
+		Follow_up_groups: CopyOuterSLICES(cmd_type.Follow_up_groups),
+		Expansion:        cmd_type.Expansion,
+	}
+
+	return detector.updateCmdsSet(func(cmds_set *cmdsSet) error {
+		var cmd_types map[string]*CmdType = make(map[string]*CmdType, len(cmds_set.cmd_types)+1)
+		for name, registered_type := range cmds_set.cmd_types {
+			cmd_types[name] = registered_type
+		}
+		cmd_types[new_cmd_type.Name] = &new_cmd_type
+		cmds_set.cmd_types = cmd_types
+
+		return nil
+	})
+}
+
+/*
+RegisterCmdTypeStr is the same as RegisterCmdType(), but with the type in a string (for when structs can't be used).
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd_type_str – the command type in the format "name||trigger words||follow-up groups||expansion", with the trigger
+    words and the follow-up groups separated by spaces. Example: "turn_updown||turn||up/down||variants"
+
+– Returns:
+  - same as in RegisterCmdType()
+*/
+func (detector *Detector) RegisterCmdTypeStr(cmd_type_str string) error {
+	var cmd_type_info []string = strings.Split(cmd_type_str, "||")
+	if len(cmd_type_info) != 4 {
+		return fmt.Errorf("command type %q: has %d fields separated by \"||\" instead of 4", cmd_type_str,
+			len(cmd_type_info))
+	}
+
+	var cmd_type CmdType = CmdType{
+		Name:      cmd_type_info[0],
+		Expansion: cmd_type_info[3],
+	}
+	if cmd_type_info[1] != "" {
+		cmd_type.Trigger_words = strings.Split(cmd_type_info[1], " ")
+	}
+	if cmd_type_info[2] != "" {
+		cmd_type.Follow_up_groups = strings.Split(cmd_type_info[2], " ")
+	}
+
+	return detector.RegisterCmdType(cmd_type)
+}
+
+/*
+validateCmdType checks if a command type can be registered.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd_type – the command type
+
+– Returns:
+  - nil if it's valid, or an error with all the problems found on it otherwise
+*/
+func validateCmdType(cmd_type CmdType) error {
+	var errs []error = nil
+	var addError = func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("command type %q: "+format, append([]any{cmd_type.Name}, args...)...))
+	}
+
+	var only_digits bool = true
+	for _, char := range cmd_type.Name {
+		if (char < 'a' || char > 'z') && (char < '0' || char > '9') && char != '_' {
+			addError("the name can only have lowercase letters, digits and \"_\"")
+
+			break
+		}
+		only_digits = only_digits && char >= '0' && char <= '9'
+	}
+	if only_digits {
+		addError("the name can't be empty nor only digits")
+	}
+	if _, ok := builtin_cmd_types_map_GL[cmd_type.Name]; ok {
+		addError("the built-in types can't be replaced")
+	}
+
+	if len(cmd_type.Trigger_words) == 0 {
+		addError("the type has no trigger words")
+	}
+	for _, word := range cmd_type.Trigger_words {
+		if !isValidPhrase(word) || strings.ContainsAny(word, " |/+;[]@#") {
+			addError("%q is not a valid trigger word", word)
+		}
+	}
+
+	for _, words_group := range cmd_type.Follow_up_groups {
+		if _, err := parseWordsGroup(words_group); err != nil {
+			addError("follow-up group: %v", err)
+		}
+	}
+
+	switch cmd_type.Expansion {
+		case CMD_TYPE_EXPAND_GROUPS, CMD_TYPE_EXPAND_TRIGGERS:
+			// Nothing else to check
+		case CMD_TYPE_EXPAND_VARIANTS:
+			if len(cmd_type.Follow_up_groups) == 0 {
+				addError("the expansion %q needs follow-up groups", CMD_TYPE_EXPAND_VARIANTS)
+			}
+		default:
+			addError("unknown expansion %q (must be %q, %q or %q)", cmd_type.Expansion, CMD_TYPE_EXPAND_GROUPS,
+				CMD_TYPE_EXPAND_VARIANTS, CMD_TYPE_EXPAND_TRIGGERS)
+	}
+
+	return errors.Join(errs...)
+}
+
+/*
+expandCmdType expands the conditions of a command with a command type.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd_type – the command type
+  - words_list – the conditions of the command
+  - main_words_ret_conds – the return conditions of the main words of the command, one per condition or with the last
+    one being used for the rest (nil if there are none)
+
+– Returns:
+  - the expanded conditions
+  - the return conditions for the expanded conditions (same rules as the given ones)
+*/
+func expandCmdType(cmd_type *CmdType, words_list []cmdCondition, main_words_ret_conds [][]string) ([]cmdCondition,
+	[][]string) {
+	var follow_up_groups []wordsGroup = nil
+	for _, words_group_str := range cmd_type.Follow_up_groups {
+		// Already validated when the type was registered.
+		words_group, _ := parseWordsGroup(words_group_str)
+		follow_up_groups = append(follow_up_groups, words_group)
+	}
+
+	var retCondOf = func(condition int) []string {
+		if len(main_words_ret_conds) == 0 {
+			return []string{ANY_MAIN_WORD}
+		} else if condition >= len(main_words_ret_conds) {
+			return main_words_ret_conds[len(main_words_ret_conds)-1]
+		}
+
+		return main_words_ret_conds[condition]
+	}
+
+	var new_words_list []cmdCondition = nil
+	var new_ret_conds [][]string = nil
+	switch cmd_type.Expansion {
+		case CMD_TYPE_EXPAND_GROUPS:
+			for _, condition := range words_list {
+				new_words_list = append(new_words_list, appendWordsGroups(condition, follow_up_groups))
+			}
+			new_ret_conds = main_words_ret_conds
+		case CMD_TYPE_EXPAND_TRIGGERS:
+			for _, trigger_word := range cmd_type.Trigger_words {
+				for _, condition := range words_list {
+					new_words_list = append(new_words_list, appendWordsGroups(condition, follow_up_groups))
+					new_ret_conds = append(new_ret_conds, []string{trigger_word})
+				}
+			}
+		case CMD_TYPE_EXPAND_VARIANTS:
+			// One words group for each word of each follow-up group, and then all their combinations, with the words of
+			// the first group changing the slowest ("on wifi", "on bluetooth", "off wifi", "off bluetooth").
+			var combinations [][]wordsGroup = [][]wordsGroup{nil}
+			for _, words_group := range follow_up_groups {
+				var new_combinations [][]wordsGroup = nil
+				for _, combination := range combinations {
+					for _, word := range words_group.words {
+						if word == NONE {
+							continue
+						}
+						var single_word_group wordsGroup = wordsGroup{
+							allowed_indexes: words_group.allowed_indexes,
+							words:           []string{word},
+						}
+						new_combinations = append(new_combinations, append(CopyOuterSLICES(combination),
+							single_word_group))
+					}
+				}
+				combinations = new_combinations
+			}
+			for _, combination := range combinations {
+				for i, condition := range words_list {
+					new_words_list = append(new_words_list, appendWordsGroups(condition, combination))
+					new_ret_conds = append(new_ret_conds, retCondOf(i))
+				}
+			}
+	}
+
+	return new_words_list, new_ret_conds
+}
+
+/*
+expandedConditionsCount gets the number of conditions of a command after expandCmdType() expands them with its types,
+without expanding them.
+
+-----------------------------------------------------------
+
+– Params:
+  - types – the types of the command
+  - num_conditions – the number of conditions of the command before the expansion
+
+– Returns:
+  - the number of conditions after the expansion, or MAX_SUB_CMDS if it's MAX_SUB_CMDS or more
+*/
+func expandedConditionsCount(types []*CmdType, num_conditions int) int {
+	var multiply = func(factor int) {
+		num_conditions *= factor
+		if num_conditions > MAX_SUB_CMDS {
+			// Stop here, before it overflows.
+			num_conditions = MAX_SUB_CMDS
+		}
+	}
+
+	for _, cmd_type := range types {
+		switch cmd_type.Expansion {
+			case CMD_TYPE_EXPAND_TRIGGERS:
+				multiply(len(cmd_type.Trigger_words))
+			case CMD_TYPE_EXPAND_VARIANTS:
+				for _, words_group_str := range cmd_type.Follow_up_groups {
+					// Already validated when the type was registered.
+					words_group, _ := parseWordsGroup(words_group_str)
+					var num_words int = 0
+					for _, word := range words_group.words {
+						if word != NONE {
+							num_words++
+						}
+					}
+					multiply(num_words)
+				}
+		}
+	}
+
+	return num_conditions
+}
+
+/*
+appendWordsGroups appends words groups to a condition, without changing the original condition.
+
+-----------------------------------------------------------
+
+– Params:
+  - condition – the condition
+  - words_groups – the words groups to append
+
+– Returns:
+  - a new condition with the words groups appended
+*/
+func appendWordsGroups(condition cmdCondition, words_groups []wordsGroup) cmdCondition {
+	var new_words_groups []wordsGroup = make([]wordsGroup, 0, len(condition.words_groups)+len(words_groups))
+	new_words_groups = append(new_words_groups, condition.words_groups...)
+
+	return cmdCondition{
+		words_groups: append(new_words_groups, words_groups...),
+	}
+}
@@ -22,11 +22,15 @@
 package ACD
 
 import (
+	"errors"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 )
 
-// The value of each TYPE constant is its index on the cmds_types_keywords array
+// The value of each TYPE constant is its index on the builtin_cmd_types array (the types can also be referred to by their
+// names - check CmdType)
 
 const CMDi_TYPE_NONE string = "0"
 const CMDi_TYPE_TURN_ONFF string = "1"
@@ -39,157 +43,323 @@ const CMDi_TYPE_REPEAT_SPEECH string = "7"
 const CMDi_TYPE_START string = "8"
 const CMDi_TYPE_WILL_GO string = "9"
 
-// Each list of type keywords can have at most 2 arrays inside it (if more are needed, change the implementation, maybe
-// even generalize it - for now it's made for case of 1 array and case of 2 arrays).
-// The 2 arrays are of words that must be mixed with the command keywords to create the command ("turn", "on" + "wifi",
-// for example).
-var cmds_types_keywords = [...][][]string{
-	{}, // 0
-	{ // 1
-		{"turn", "get", "switch", "put"}, // Default main words for this type to put be on the main_words array
-		{"on", "off"},                    // Other optional words to continue the first ones if necessary (turn... what?
-		                                  // something. what? on or off)
-	},
-	{ // 2
-		{"what's", "what", "tell", "say", "how", "how's", "how're"},
-	},
-	{ // 3
-		{"stop", "end", "finish", "cease", "conclude", "terminate"},
-	},
-	{ // 4
-		{"answer", "reply", "respond", "acknowledge"},
-	},
-	{ // 5
-		{"shut", "power"},
-		{"down", "off"},
-	},
-	{ // 6
-		{"reboot", "restart"},
-	},
-	{ // 7
-		{"what", "say", "come", "go", "repeat"},
-	},
-	{ // 8
-		{"start", "begin", "initialize", "commence"},
-	},
-	{ // 9
-		{"will", "gonna", "going", "i'll"},
-	},
+// MAIN_WORDS_PHRASE_SEP separates the words of a main word that is a phrase ("good_night" is triggered by "good night").
+// The whole phrase is the main word: it's used like that on the return conditions ("good_night" or "-good_night"), and
+// all its words are invalidated with the detection.
+// The phrases go through the sentence correction when loaded, as the sentences do before being searched ("what_is" is
+// "what's" - check correctedMainWord()).
+const MAIN_WORDS_PHRASE_SEP string = "_"
+
+/*
+AddUpdateCmd calls Detector.AddUpdateCmd() on the default detector.
+*/
+func AddUpdateCmd(command_info_str string) error {
+	return default_detector_GL.AddUpdateCmd(command_info_str)
+}
+
+/*
+RemoveCmd calls Detector.RemoveCmd() on the default detector.
+*/
+func RemoveCmd(cmd_id int) {
+	default_detector_GL.RemoveCmd(cmd_id)
+}
+
+/*
+ReloadCmdsArray calls Detector.ReloadCmdsArray() on the default detector.
+*/
+func ReloadCmdsArray(commands_str string) error {
+	return default_detector_GL.ReloadCmdsArray(commands_str)
+}
+
+/*
+AddUpdateCmd adds a command to the detector's list or updates the current one in case it already exists.
+
+The command is validated first (check ValidateCmdDefinition()). If it's not valid, nothing is changed.
+
+-----------------------------------------------------------
+
+– Params:
+  - command_info_str – the command information, as explained on main_ACD.go
+
+– Returns:
+  - nil if the command was added/updated, or an error with all the problems found on the command (each one a
+    *CmdDefinitionError) otherwise
+*/
+func (detector *Detector) AddUpdateCmd(command_info_str string) error {
+	return detector.updateCmdsSet(func(cmds_set *cmdsSet) error {
+		var err error = nil
+		cmds_set.cmds, err = addUpdateCmd(cmds_set.cmds, cmds_set.cmd_types, command_info_str)
+
+		return err
+	})
+}
+
+/*
+RemoveCmd removes a command from the detector's list based on its ID.
+*/
+func (detector *Detector) RemoveCmd(cmd_id int) {
+	_ = detector.updateCmdsSet(func(cmds_set *cmdsSet) error {
+		var cmds_index int = -1
+		for i := range cmds_set.cmds {
+			if cmd_id == cmds_set.cmds[i].cmd_id {
+				cmds_index = i
+			}
+		}
+
+		if cmds_index >= 0 {
+			DelElemSLICES(&cmds_set.cmds, cmds_index)
+		}
+
+		return nil
+	})
+}
+
+/*
+ReloadCmdsArray resets and loads all commands from scratch into the detector's commands list.
+
+The new list is only published after all the commands are loaded, so the detections never see it half-loaded. If any of
+the commands is not valid, or if an ID is repeated, nothing is changed.
+
+-----------------------------------------------------------
+
+– Params:
+  - commands_str – the commands information, as explained on main_ACD.go, separated by "\\"
+
+– Returns:
+  - nil if the commands were loaded, or an error with all the problems found on all the commands (each one a
+    *CmdDefinitionError) otherwise
+*/
+func (detector *Detector) ReloadCmdsArray(commands_str string) error {
+	return detector.updateCmdsSet(func(cmds_set *cmdsSet) error {
+		// Reset the commands array
+		cmds_set.cmds = nil
+
+		var errs []error = nil
+		var ids_found map[int]bool = make(map[int]bool)
+		for _, command_info_str := range strings.Split(commands_str, "\\") {
+			definition, parse_errs := parseCmdInfoStr(command_info_str)
+			if len(parse_errs) > 0 {
+				errs = append(errs, parse_errs...)
+
+				continue
+			}
+			// The same as on LoadCmdsFromReader() - the ones after the first would silently replace it.
+			if ids_found[definition.Id] {
+				errs = append(errs, &CmdDefinitionError{
+					Cmd_id:      definition.Id,
+					Field:       "id",
+					Description: "the ID is repeated on the commands",
+				})
+
+				continue
+			}
+			ids_found[definition.Id] = true
+
+			var err error = nil
+			if cmds_set.cmds, err = addUpdateCmdDefinition(cmds_set.cmds, cmds_set.cmd_types, definition); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		//log.Println(len(cmds_set.cmds))
+		//log.Println("===========")
+
+		return errors.Join(errs...)
+	})
+}
+
+/*
+addUpdateCmd adds a command to a list of commands or updates the current one in case it already exists.
+
+The command is always loaded into a new commandInfo, as the old one may still be in use by detections.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmds – the list of commands (must not be a published one, but a copy - check updateCmdsSet())
+  - cmd_types – the command types that can be used on the command
+  - command_info_str – the command information, as explained on main_ACD.go
+
+– Returns:
+  - the updated list, or the original one if an error occurred
+  - nil if the command was added/updated, or an error with all the problems found on the command otherwise
+*/
+func addUpdateCmd(cmds []commandInfo, cmd_types map[string]*CmdType, command_info_str string) ([]commandInfo, error) {
+	definition, errs := parseCmdInfoStr(command_info_str)
+	if len(errs) > 0 {
+		return cmds, errors.Join(errs...)
+	}
+
+	return addUpdateCmdDefinition(cmds, cmd_types, definition)
 }
 
 /*
-AddUpdateCmd adds a command to the list or updates the current one in case it already exists.
+parseCmdInfoStr converts the command information string given to AddUpdateCmd() to a CmdDefinition.
+
+-----------------------------------------------------------
+
+– Params:
+  - command_info_str – same as in addUpdateCmd()
+
+– Returns:
+  - the definition of the command
+  - the list of the problems found on the string (the definition itself is not validated here)
 */
-func AddUpdateCmd(command_info_str string) {
+func parseCmdInfoStr(command_info_str string) (CmdDefinition, []error) {
 	var cmd_info []string = strings.Split(command_info_str, "||")
+	if len(cmd_info) < 5 {
+		return CmdDefinition{}, []error{&CmdDefinitionError{
+			Cmd_id:      0,
+			Field:       "command string",
+			Description: fmt.Sprintf("%q has %d fields separated by \"||\" instead of 5", command_info_str, len(cmd_info)),
+		}}
+	}
 
-	cmd_id, _ := strconv.Atoi(cmd_info[0])
+	cmd_id, err := strconv.Atoi(cmd_info[0])
+	if err != nil {
+		return CmdDefinition{}, []error{&CmdDefinitionError{
+			Cmd_id:      0,
+			Field:       "id",
+			Description: fmt.Sprintf("%q is not an integer", cmd_info[0]),
+		}}
+	}
 	var types_str []string = strings.Split(cmd_info[1], "+")
 	var main_words_manual []string = nil
 	if "" != cmd_info[2] {
 		main_words_manual = strings.Split(cmd_info[2], " ")
 	}
-	var main_words_ret_conds_str string = ""
-	if "" != cmd_info[2] {
-		main_words_ret_conds_str = cmd_info[3]
+	var main_words_ret_conds []string = nil
+	if "" != cmd_info[2] && "" != cmd_info[3] {
+		main_words_ret_conds = strings.Split(cmd_info[3], "|")
 	}
 	var words_list_param []string = strings.Split(cmd_info[4], "|")
 
-	if (cmd_id <= 0) || (len(types_str) == 0) || (len(words_list_param) == 0) {
-		return
-	}
+	return CmdDefinition{
+		Id:                   cmd_id,
+		Types:                types_str,
+		Main_words:           main_words_manual,
+		Main_words_ret_conds: main_words_ret_conds,
+		Words_list:           words_list_param,
+	}, nil
+}
 
-	var cmds_GL_index int = -1
-	for i := range cmds_GL {
-		if cmd_id == cmds_GL[i].cmd_id {
-			cmds_GL_index = i
-		}
-	}
-	if cmds_GL_index < 1 {
-		cmds_GL = append(cmds_GL, commandInfo{
-			cmd_id:                           cmd_id,
-			main_words:                       nil,
-			main_words_ret_conds:             nil,
-			words_list:                       nil,
-			left_intervs:                     nil,
-			right_intervs:                    nil,
-			init_indexes_sub_verifs:          nil,
-			exclude_word_found_group:         nil,
-			ignore_repets_cmds:               false,
-			exclude_mutually_exclusive_words: true,
-		})
+/*
+addUpdateCmdDefinition is the same as addUpdateCmd() but for a command in the CmdDefinition form.
 
-		cmds_GL_index = len(cmds_GL) - 1
-	}
+-----------------------------------------------------------
 
-	loadCmdToArray(&cmds_GL[cmds_GL_index], types_str, main_words_manual, main_words_ret_conds_str, words_list_param)
-}
+– Params:
+  - cmds – same as in addUpdateCmd()
+  - cmd_types – same as in addUpdateCmd()
+  - definition – the definition of the command
 
-/*
-RemoveCmd removes a command from the list based on its ID.
+– Returns:
+  - the updated list, or the original one if an error occurred
+  - nil if the command was added/updated, or an error with all the problems found on the command otherwise
 */
-func RemoveCmd(cmd_id int) {
-	var cmds_GL_index int = -1
-	for i := range cmds_GL {
-		if cmd_id == cmds_GL[i].cmd_id {
-			cmds_GL_index = i
-		}
+func addUpdateCmdDefinition(cmds []commandInfo, cmd_types map[string]*CmdType, definition CmdDefinition) (
+	[]commandInfo, error) {
+	if errs := validateCmdDefinition(definition, cmd_types); len(errs) > 0 {
+		return cmds, errors.Join(errs...)
+	}
+
+	var new_cmd_info commandInfo = newCmdInfo(definition.Id)
+	if err := loadCmdToArray(&new_cmd_info, cmd_types, definition); err != nil {
+		return cmds, err
 	}
 
-	if cmds_GL_index >= 1 {
-		DelElemSLICES(&cmds_GL, cmds_GL_index)
+	var cmds_index int = -1
+	for i := range cmds {
+		if definition.Id == cmds[i].cmd_id {
+			cmds_index = i
+		}
+	}
+	if cmds_index < 0 {
+		cmds = append(cmds, new_cmd_info)
+	} else {
+		cmds[cmds_index] = new_cmd_info
 	}
+
+	return cmds, nil
 }
 
 /*
-ReloadCmdsArray resets and loads all commands from scratch into the commands list.
-*/
-func ReloadCmdsArray(commands_str string) {
-	// Reset the commands array
-	cmds_GL = nil
+newCmdInfo creates an empty commandInfo with the default parameters.
 
-	var commands_info []string = strings.Split(commands_str, "\\")
+-----------------------------------------------------------
 
-	for i := range commands_info {
-		AddUpdateCmd(commands_info[i])
-	}
+– Params:
+  - cmd_id – the ID of the command
 
-	//log.Println(len(cmds_GL))
-	//log.Println("===========")
+– Returns:
+  - the new commandInfo
+*/
+func newCmdInfo(cmd_id int) commandInfo {
+	return commandInfo{
+		cmd_id:                           cmd_id,
+		main_words:                       nil,
+		main_words_ret_conds:             nil,
+		words_list:                       nil,
+		left_intervs:                     nil,
+		right_intervs:                    nil,
+		init_indexes_sub_verifs:          nil,
+		exclude_word_found_group:         nil,
+		ignore_repets_cmds:               false,
+		exclude_mutually_exclusive_words: true,
+	}
 }
 
 /*
-loadCmdToArray loads a command into the list.
+loadCmdToArray loads a command definition into a commandInfo.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd_info – the commandInfo to load the command into
+  - cmd_types – the command types that can be used on the command
+  - definition – the definition of the command
+
+– Returns:
+  - an error if the definition could not be loaded, nil otherwise
 */
-func loadCmdToArray(cmd_info_GL *commandInfo, types_str []string, main_words_manual []string,
-	main_words_ret_conds_str string, words_list_param []string) {
-	var types_int []int = nil
-	for _, j := range types_str {
-		type_int, _ := strconv.Atoi(j)
-		types_int = append(types_int, type_int)
+func loadCmdToArray(cmd_info *commandInfo, cmd_types map[string]*CmdType, definition CmdDefinition) error {
+	// Keep the definition as it was given, to be able to export it again.
+	cmd_info.definition = definition
+
+	var main_words_manual []string = definition.Main_words
+	var words_list_param []string = CopyOuterSLICES(definition.Words_list)
+
+	var types []*CmdType = nil
+	for _, type_str := range definition.Types {
+		cmd_type, ok := cmd_types[type_str]
+		if !ok {
+			return fmt.Errorf("unknown command type %q", type_str)
+		}
+		types = append(types, cmd_type)
 	}
 
 	/////////////////////////////////
 	// Arrays processing
 
 	// main_words
-	for i, j := range types_str {
-		if j != CMDi_TYPE_NONE {
-			cmd_info_GL.main_words = append(cmd_info_GL.main_words, cmds_types_keywords[types_int[i]][0]...)
-		}
+	for _, cmd_type := range types {
+		cmd_info.main_words = append(cmd_info.main_words, cmd_type.Trigger_words...)
 	}
 	if len(main_words_manual) > 0 {
-		cmd_info_GL.main_words = append(cmd_info_GL.main_words, main_words_manual...)
+		cmd_info.main_words = append(cmd_info.main_words, main_words_manual...)
+	}
+	for i, main_word := range cmd_info.main_words {
+		cmd_info.main_words[i] = correctedMainWord(main_word)
 	}
 
-	//log.Println(cmd_info_GL.main_words)
+	//log.Println(cmd_info.main_words)
 
 	// words_list
 	// "device/phone safe mode|device/phone recovery|device/phone"
-	var words_list [][][][]interface{} = nil
+	var words_list []cmdCondition = nil
 
-	if "" != main_words_ret_conds_str {
-		var main_words_ret_conds_len int = len(strings.Split(main_words_ret_conds_str, "|"))
+	if len(definition.Main_words_ret_conds) > 0 {
+		var main_words_ret_conds_len int = len(definition.Main_words_ret_conds)
 		var last_words_list_param_index int = len(words_list_param) - 1
 		for i := 0; i < main_words_ret_conds_len; i++ {
 			if len(words_list_param) >= main_words_ret_conds_len {
@@ -203,58 +373,378 @@ func loadCmdToArray(cmd_info_GL *commandInfo, types_str []string, main_words_man
 	}
 
 	for condition_str_num, condition_str := range words_list_param {
-		words_list = append(words_list, nil)
-		for ii, words_group := range strings.Split(condition_str, " ") {
-			words_list[condition_str_num] = append(words_list[condition_str_num], nil)
-			words_list[condition_str_num][ii] = append(words_list[condition_str_num][ii], []interface{}{-1})
-			words_list[condition_str_num][ii] = append(words_list[condition_str_num][ii], nil)
-			for _, word := range strings.Split(words_group, "/") {
-				words_list[condition_str_num][ii][1] = append(words_list[condition_str_num][ii][1], word)
+		var condition cmdCondition = cmdCondition{}
+		for _, words_group_str := range strings.Split(condition_str, " ") {
+			words_group, err := parseWordsGroup(words_group_str)
+			if err != nil {
+				return fmt.Errorf("words_list[%d]: %w", condition_str_num, err)
 			}
+			condition.words_groups = append(condition.words_groups, words_group)
 		}
+		words_list = append(words_list, condition)
 	}
-	for i, j := range types_str {
-		switch j {
-			case CMDi_TYPE_TURN_ONFF: {
-				words_list[0] = append(words_list[0], nil)
-				var words_list_0_len int = len(words_list[0])
-				words_list[0][words_list_0_len-1] = append(words_list[0][words_list_0_len-1], []interface{}{-1})
-				words_list[0][words_list_0_len-1] = append(words_list[0][words_list_0_len-1], []interface{}{"on"})
-
-				words_list = append(words_list, nil)
-				CopyFullSLICES(&words_list[1], words_list[0])
-				words_list[1][words_list_0_len-1][1][0] = "off"
-			}
-			default: {
-				if len(cmds_types_keywords[types_int[i]]) == 2 {
-					for ii := range words_list {
-						words_list[ii] = append(words_list[ii], nil)
-						var words_list_i_len int = len(words_list[ii])
-						words_list[ii][words_list_i_len-1] = append(words_list[ii][words_list_i_len-1], []interface{}{-1})
-						words_list[ii][words_list_i_len-1] = append(words_list[ii][words_list_i_len-1], nil)
-						for _, j := range cmds_types_keywords[types_int[i]][1] {
-							words_list[ii][words_list_i_len-1][1] = append(words_list[ii][words_list_i_len-1][1], j)
-						}
-					}
-				}
+
+	// main_words_ret_conds
+	var main_words_ret_conds [][]string = nil
+	for _, j := range definition.Main_words_ret_conds {
+		main_words_ret_conds = append(main_words_ret_conds, strings.Split(j, " "))
+	}
+
+	// Each type adds its follow-up groups to the conditions and may create more variants from them.
+	for _, cmd_type := range types {
+		words_list, main_words_ret_conds = expandCmdType(cmd_type, words_list, main_words_ret_conds)
+	}
+	cmd_info.words_list = words_list
+	//log.Println(cmd_info.words_list)
+
+	if len(main_words_ret_conds) == 0 {
+		main_words_ret_conds = append(main_words_ret_conds, []string{ANY_MAIN_WORD})
+	}
+	for i, ret_cond := range main_words_ret_conds {
+		// New slices, as they may be the ones of the types.
+		var corrected_ret_cond []string = make([]string, 0, len(ret_cond))
+		for _, word := range ret_cond {
+			if strings.HasPrefix(word, "-") {
+				corrected_ret_cond = append(corrected_ret_cond, "-"+correctedMainWord(word[1:]))
+			} else {
+				corrected_ret_cond = append(corrected_ret_cond, correctedMainWord(word))
 			}
 		}
+		main_words_ret_conds[i] = corrected_ret_cond
 	}
-	cmd_info_GL.words_list = words_list
-	//log.Println(cmd_info_GL.words_list)
+	cmd_info.main_words_ret_conds = main_words_ret_conds
+	//log.Println(cmd_info.main_words_ret_conds)
 
-	// main_words_ret_conds
-	if main_words_ret_conds_str == "" {
-		cmd_info_GL.main_words_ret_conds = append(cmd_info_GL.main_words_ret_conds, []string{ANY_MAIN_WORD})
+	normalizeConditionsOrder(cmd_info)
+
+	// exclude_word_found
+	if definition.Exclude_word_found_group == nil {
+		cmd_info.exclude_word_found_group = append(cmd_info.exclude_word_found_group, ALL_SUB_VERIFS_INT)
 	} else {
-		for _, j := range strings.Split(main_words_ret_conds_str, "|") {
-			cmd_info_GL.main_words_ret_conds = append(cmd_info_GL.main_words_ret_conds, strings.Split(j, " "))
+		cmd_info.exclude_word_found_group = CopyOuterSLICES(*definition.Exclude_word_found_group)
+	}
+
+	// The other parameters
+	var err error = nil
+	if cmd_info.left_intervs, err = parseSubVerifsMap(definition.Left_intervs); err != nil {
+		return fmt.Errorf("left_intervs: %w", err)
+	}
+	if cmd_info.right_intervs, err = parseSubVerifsMap(definition.Right_intervs); err != nil {
+		return fmt.Errorf("right_intervs: %w", err)
+	}
+	if cmd_info.init_indexes_sub_verifs, err = parseSubVerifsMap(definition.Init_indexes_sub_verifs); err != nil {
+		return fmt.Errorf("init_indexes_sub_verifs: %w", err)
+	}
+	cmd_info.ignore_repets_cmds = definition.Ignore_repets_cmds
+	cmd_info.exclude_main_words = definition.Exclude_main_words
+	if cmd_info.exclude_main_words {
+		// Exclude the main words from the conditions here, once, instead of on every verification.
+		for i := range cmd_info.words_list {
+			for ii := range cmd_info.words_list[i].words_groups {
+				var words_group *wordsGroup = &cmd_info.words_list[i].words_groups[ii]
+				words_group.words = removeWords(words_group.words, func(word string) bool {
+					return isInSlice(cmd_info.main_words, word)
+				})
+			}
 		}
 	}
-	//log.Println(cmd_info_GL.main_words_ret_conds)
 
-	// exclude_word_found
-	cmd_info_GL.exclude_word_found_group = append(cmd_info_GL.exclude_word_found_group, ALL_SUB_VERIFS_INT)
+	cmd_info.tag_overrides = cmdTagOverrides(cmd_info)
 
 	//log.Println("---------")
+
+	return nil
+}
+
+/*
+cmdTagOverrides gets the part-of-speech tags a command gives to its words, to be applied over the ones of the tagger on
+the NLP analysis: its main words are verbs ("VB") and the words of its conditions that are not also its main words are
+names ("NN"). The words of lexicon_function_words_tags ("i", "on", "again"...) get no tag - they're not verbs nor names.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd_info – the command, already loaded
+
+– Returns:
+  - the tags, by word
+*/
+func cmdTagOverrides(cmd_info *commandInfo) map[string]string {
+	var tag_overrides map[string]string = make(map[string]string)
+	for _, main_word := range cmd_info.main_words {
+		for _, word := range strings.Split(main_word, MAIN_WORDS_PHRASE_SEP) {
+			if _, ok := lexicon_function_words_tags[word]; !ok {
+				tag_overrides[word] = "VB"
+			}
+		}
+	}
+	for _, condition := range cmd_info.words_list {
+		for _, words_group := range condition.words_groups {
+			for _, word := range words_group.words {
+				if _, ok := tag_overrides[word]; ok || word == NONE || word == IS_DIGIT {
+					continue
+				}
+				if _, ok := lexicon_function_words_tags[word]; !ok {
+					tag_overrides[word] = "NN"
+				}
+			}
+		}
+	}
+
+	return tag_overrides
+}
+
+/*
+parseWordsGroup converts a words group of the compact syntax of the conditions to a wordsGroup.
+
+The words are separated by "/", and the group can also have:
+  - "[" and "]" around the words, to make the group optional ("[rear]" is the same as "rear/;0;" - NONE)
+  - "#" as a word, for a digit ("#" is the same as ";1;" - IS_DIGIT)
+  - "@" and the allowed position indexes separated by "," in the end ("on/off@0" or "[rear]@0,1"), to only accept the
+    group in those positions (with no "@", the group is accepted in any position - ALL_SUB_VERIFS_INT)
+
+Example: "[the] on/off@0 #" - an optional "the", then "on" or "off" as the first word found, and then a digit.
+
+-----------------------------------------------------------
+
+– Params:
+  - words_group – the words group
+
+– Returns:
+  - the words group
+  - an error if the syntax is wrong, nil otherwise
+*/
+func parseWordsGroup(words_group string) (wordsGroup, error) {
+	var indexes []int = []int{ALL_SUB_VERIFS_INT}
+	if at_index := strings.LastIndex(words_group, "@"); at_index >= 0 {
+		indexes = nil
+		for _, index_str := range strings.Split(words_group[at_index+1:], ",") {
+			index, err := strconv.Atoi(index_str)
+			if err != nil || index < 0 {
+				return wordsGroup{}, fmt.Errorf("words group %q has an invalid position index: %q", words_group, index_str)
+			}
+			indexes = append(indexes, index)
+		}
+		words_group = words_group[:at_index]
+	}
+
+	var optional bool = false
+	if strings.HasPrefix(words_group, "[") || strings.HasSuffix(words_group, "]") {
+		if !strings.HasPrefix(words_group, "[") || !strings.HasSuffix(words_group, "]") || len(words_group) < 2 {
+			return wordsGroup{}, fmt.Errorf("words group %q has an unclosed \"[\"", words_group)
+		}
+		optional = true
+		words_group = words_group[1 : len(words_group)-1]
+	}
+	if words_group == "" {
+		return wordsGroup{}, errors.New("empty words group (groups must be separated by one space)")
+	}
+
+	var words []string = nil
+	for _, word := range strings.Split(words_group, "/") {
+		switch {
+			case word == "":
+				return wordsGroup{}, fmt.Errorf("words group %q has an empty word", words_group)
+			case word == "#":
+				word = IS_DIGIT
+			case word == NONE || word == IS_DIGIT:
+				// Allowed special words
+			case isSpecialCommand(word) || strings.ContainsAny(word, ";[]@#"):
+				return wordsGroup{}, fmt.Errorf("%q is not a valid word (the only special words allowed are \"#\" "+
+					"(or %q) and %q)", word, IS_DIGIT, NONE)
+		}
+		words = append(words, word)
+	}
+	if optional {
+		words = append(words, NONE)
+	}
+
+	var only_none bool = true
+	for _, word := range words {
+		only_none = only_none && word == NONE
+	}
+	if only_none {
+		return wordsGroup{}, fmt.Errorf("words group %q only has %q", words_group, NONE)
+	}
+
+	return wordsGroup{
+		allowed_indexes: indexes,
+		words:           words,
+	}, nil
+}
+
+/*
+normalizeConditionsOrder reorders the conditions of a loaded command to follow wordsVerificationFunction()'s ordering
+rule: conditions with NONE above all the ones without it and, after that, conditions with more words groups above the
+ones with fewer. Conditions that are equal in both keep their relative order (the first one still wins a tie).
+
+The return conditions of the main words are reordered with them (one for each condition, as each condition may have
+moved away from its return condition), and the original variant of each condition is kept on 'variants', so the
+detected variant numbers are still the ones of the definition.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd_info – the loaded command
+*/
+func normalizeConditionsOrder(cmd_info *commandInfo) {
+	var num_conditions int = len(cmd_info.words_list)
+
+	cmd_info.variants = make([]int, num_conditions)
+	for i := range cmd_info.variants {
+		cmd_info.variants[i] = i
+	}
+	sort.SliceStable(cmd_info.variants, func(i, j int) bool {
+		var condition_i cmdCondition = cmd_info.words_list[cmd_info.variants[i]]
+		var condition_j cmdCondition = cmd_info.words_list[cmd_info.variants[j]]
+		var none_i bool = conditionHasNONE(condition_i)
+		var none_j bool = conditionHasNONE(condition_j)
+		if none_i != none_j {
+			return none_i
+		}
+
+		return len(condition_i.words_groups) > len(condition_j.words_groups)
+	})
+
+	var words_list []cmdCondition = make([]cmdCondition, num_conditions)
+	var main_words_ret_conds [][]string = make([][]string, num_conditions)
+	var ret_conds_len int = len(cmd_info.main_words_ret_conds)
+	for i, variant := range cmd_info.variants {
+		words_list[i] = cmd_info.words_list[variant]
+		if variant < ret_conds_len {
+			main_words_ret_conds[i] = cmd_info.main_words_ret_conds[variant]
+		} else {
+			// In case there are not enough return conditions, the last one present is used.
+			main_words_ret_conds[i] = cmd_info.main_words_ret_conds[ret_conds_len-1]
+		}
+	}
+	cmd_info.words_list = words_list
+	cmd_info.main_words_ret_conds = main_words_ret_conds
+}
+
+/*
+conditionHasNONE checks if any of the words groups of a condition has NONE.
+
+-----------------------------------------------------------
+
+– Params:
+  - condition – the condition
+
+– Returns:
+  - true if any group has NONE, false otherwise
+*/
+func conditionHasNONE(condition cmdCondition) bool {
+	for _, words_group := range condition.words_groups {
+		if words_group.isOptional() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sub_verifs_keys_names are the names that can be used on the definitions instead of the numbers of the constants for
+// the keys of the sub-verifications maps ({"odd": 6} instead of {"-3": 6}).
+var sub_verifs_keys_names = map[string]int{
+	"all":  ALL_SUB_VERIFS_INT,
+	"even": INDEX_EVEN,
+	"odd":  INDEX_ODD,
+}
+
+/*
+parseSubVerifsMap converts a map of a CmdDefinition whose keys are sub-verification numbers in strings to the map used
+on commandInfo, with the keys as ints.
+
+-----------------------------------------------------------
+
+– Params:
+  - definition_map – the map from the CmdDefinition
+
+– Returns:
+  - the converted map, or nil if the given one is empty
+  - an error if a key is not valid, nil otherwise
+*/
+func parseSubVerifsMap[T any](definition_map map[string]T) (map[int]T, error) {
+	if len(definition_map) == 0 {
+		return nil, nil
+	}
+
+	var sub_verifs_map map[int]T = make(map[int]T, len(definition_map))
+	for key, value := range definition_map {
+		sub_verif, err := parseSubVerifKey(key)
+		if err != nil {
+			return nil, err
+		}
+		sub_verifs_map[sub_verif] = value
+	}
+
+	return sub_verifs_map, nil
+}
+
+/*
+parseSubVerifKey converts a key of a sub-verifications map of a CmdDefinition to the number used on commandInfo.
+
+-----------------------------------------------------------
+
+– Params:
+  - key – a sub-verification number, a number of one of the constants, or one of the names on sub_verifs_keys_names
+
+– Returns:
+  - the sub-verification number or the constant
+  - an error if the key is not valid, nil otherwise
+*/
+func parseSubVerifKey(key string) (int, error) {
+	if sub_verif, ok := sub_verifs_keys_names[key]; ok {
+		return sub_verif, nil
+	}
+
+	sub_verif, err := strconv.Atoi(key)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sub-verification number %q", key)
+	}
+
+	return sub_verif, nil
+}
+
+/*
+removeWords removes words from a slice of words, keeping the order of the others.
+
+-----------------------------------------------------------
+
+– Params:
+  - words – the words (not modified, as the words groups may share them)
+  - remove – function that returns true for the words to remove
+
+– Returns:
+  - a new slice with the words that were kept
+*/
+func removeWords(words []string, remove func(word string) bool) []string {
+	var kept_words []string = make([]string, 0, len(words))
+	for _, word := range words {
+		if !remove(word) {
+			kept_words = append(kept_words, word)
+		}
+	}
+
+	return kept_words
+}
+
+/*
+correctedMainWord applies the sentence correction to a main word (or phrase), as the sentences get it before being
+searched for the main words - else some would never be found: "what is" is always corrected to "what's", so "what_is"
+is "what's" (and "shutdown" is "shut_down").
+
+-----------------------------------------------------------
+
+– Params:
+  - main_word – the main word
+
+– Returns:
+  - the corrected main word
+*/
+func correctedMainWord(main_word string) string {
+	var phrase string = strings.Replace(main_word, MAIN_WORDS_PHRASE_SEP, " ", -1)
+	phrase = sentenceCorrection(phrase, nil, true)
+
+	return strings.Replace(phrase, " ", MAIN_WORDS_PHRASE_SEP, -1)
 }
@@ -0,0 +1,266 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CMDS_FORMAT_JSON is the format name of the JSON commands definitions files.
+const CMDS_FORMAT_JSON string = "json"
+// CMDS_FORMAT_YAML is the format name of the YAML commands definitions files.
+const CMDS_FORMAT_YAML string = "yaml"
+
+/*
+CmdDefinition is the definition of a command, as written on the JSON/YAML commands definitions files. It has the same
+information as the string given to AddUpdateCmd(), plus all the other parameters of the command that the string can't
+have. The meaning of each of the parameters is explained on wordsVerificationFunction().
+
+A commands definitions file has a single "commands" list with the definitions. JSON example:
+
+	{
+		"commands": [
+			{
+				"id": 14,
+				"types": ["6"],
+				"main_words": ["fast"],
+				"main_words_ret_conds": ["fast", ";4; -fast"],
+				"words_list": ["reboot/restart device/phone", "device/phone", "device/phone recovery"],
+				"right_intervs": {"-1": 3}
+			}
+		]
+	}
+
+And the same in YAML (which also allows comments):
+
+	commands:
+	  # Reboot the device (fast reboot, normal reboot, or reboot into recovery)
+	  - id: 14
+	    types: ["6"]
+	    main_words: [fast]
+	    main_words_ret_conds: [fast, ";4; -fast"]
+	    words_list:
+	      - reboot/restart device/phone
+	      - device/phone
+	      - device/phone recovery
+	    right_intervs: {-1: 3}
+
+The fields with the sub-verification numbers as keys (the "_intervs" and "init_indexes_sub_verifs" ones) take the
+numbers as strings on JSON (JSON only allows string keys), and the constants used on them must be given with their
+values (ALL_SUB_VERIFS_INT is -1, for example).
+*/
+type CmdDefinition struct {
+	// Id is the ID of the command (a positive integer)
+	Id int `json:"id" yaml:"id"`
+	// Types is the list of the types of the command (CMDi_TYPE_-started constants or names of command types)
+	Types []string `json:"types,omitempty" yaml:"types,omitempty"`
+	// Main_words is the list of the main words of the command, other than the ones of the types. A main word can be a
+	// phrase, with the words separated by MAIN_WORDS_PHRASE_SEP ("good_night").
+	Main_words []string `json:"main_words,omitempty" yaml:"main_words,omitempty"`
+	// Main_words_ret_conds is the list of the return conditions of the main words, one per condition of the
+	// 'Words_list', each with the words separated by spaces (for example ";4; -fast")
+	Main_words_ret_conds []string `json:"main_words_ret_conds,omitempty" yaml:"main_words_ret_conds,omitempty"`
+	// Words_list is the list of the conditions of the command, each one with the words groups separated by spaces and
+	// the words of each group separated by "/" (for example "device/phone safe mode"). Groups can also be optional,
+	// have digits, and be pinned to positions (for example "[rear] video/camera@0" or "alarm for #") - check
+	// parseWordsGroup()
+	Words_list []string `json:"words_list" yaml:"words_list"`
+
+	// Left_intervs is the 'left_intervs' of the command: how many words to the left of the last word found to search
+	// for the next one, by sub-verification. The keys are sub-verification numbers, or "all", "even" or "odd" (or the
+	// numbers of the constants) - for example {"all": 2, "1": 6}
+	Left_intervs map[string]int `json:"left_intervs,omitempty" yaml:"left_intervs,omitempty"`
+	// Right_intervs is the 'right_intervs' of the command: the same as 'Left_intervs' but to the right
+	Right_intervs map[string]int `json:"right_intervs,omitempty" yaml:"right_intervs,omitempty"`
+	// Init_indexes_sub_verifs is the 'init_indexes_sub_verifs' of the command (the keys can also be "all")
+	Init_indexes_sub_verifs map[string]string `json:"init_indexes_sub_verifs,omitempty" yaml:"init_indexes_sub_verifs,omitempty"`
+	// Exclude_word_found_group is the 'exclude_word_found_group' of the command. If it's not given (nil), it will be
+	// {ALL_SUB_VERIFS_INT} (to disable it, give an empty list). A pointer so that an empty list is exported too, and
+	// isn't taken as not given when loaded back.
+	Exclude_word_found_group *[]int `json:"exclude_word_found_group,omitempty" yaml:"exclude_word_found_group,omitempty"`
+	// Ignore_repets_cmds is the 'ignore_repets_cmds' of the command
+	Ignore_repets_cmds bool `json:"ignore_repets_cmds,omitempty" yaml:"ignore_repets_cmds,omitempty"`
+	// Exclude_main_words is the 'exclude_main_words' of the command
+	Exclude_main_words bool `json:"exclude_main_words,omitempty" yaml:"exclude_main_words,omitempty"`
+}
+
+// cmdsDefinitionsFile is the root of a commands definitions file.
+type cmdsDefinitionsFile struct {
+	Commands []CmdDefinition `json:"commands" yaml:"commands"`
+}
+
+/*
+LoadCmdsFromFile calls Detector.LoadCmdsFromFile() on the default detector.
+*/
+func LoadCmdsFromFile(file_path string) error {
+	return default_detector_GL.LoadCmdsFromFile(file_path)
+}
+
+/*
+LoadCmdsFromReader calls Detector.LoadCmdsFromReader() on the default detector.
+*/
+func LoadCmdsFromReader(reader io.Reader, format string) error {
+	return default_detector_GL.LoadCmdsFromReader(reader, format)
+}
+
+/*
+ExportCmds calls Detector.ExportCmds() on the default detector.
+*/
+func ExportCmds(writer io.Writer, format string) error {
+	return default_detector_GL.ExportCmds(writer, format)
+}
+
+/*
+LoadCmdsFromFile is the same as LoadCmdsFromReader(), but reads the definitions from a file. The format is chosen
+from the file extension (".json", or ".yaml"/".yml").
+
+-----------------------------------------------------------
+
+– Params:
+  - file_path – the path to the file
+
+– Returns:
+  - an error if the file could not be read or loaded, nil otherwise
+*/
+func (detector *Detector) LoadCmdsFromFile(file_path string) error {
+	var format string = ""
+	switch strings.ToLower(filepath.Ext(file_path)) {
+		case ".json":
+			format = CMDS_FORMAT_JSON
+		case ".yaml", ".yml":
+			format = CMDS_FORMAT_YAML
+		default:
+			return fmt.Errorf("unknown commands definitions file extension: %q", filepath.Ext(file_path))
+	}
+
+	file, err := os.Open(file_path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return detector.LoadCmdsFromReader(file, format)
+}
+
+/*
+LoadCmdsFromReader resets and loads all commands from scratch into the detector's commands list (like
+ReloadCmdsArray()), but from commands definitions in JSON or YAML (check CmdDefinition for the format).
+
+If any of the commands can't be loaded, nothing is changed on the detector.
+
+-----------------------------------------------------------
+
+– Params:
+  - reader – the reader to read the definitions from
+  - format – the format of the definitions (one of the CMDS_FORMAT_-started constants)
+
+– Returns:
+  - an error if the definitions could not be read or loaded, nil otherwise (for invalid definitions, the error has all
+    the problems found on all the commands, each one a *CmdDefinitionError)
+*/
+func (detector *Detector) LoadCmdsFromReader(reader io.Reader, format string) error {
+	var definitions_file cmdsDefinitionsFile = cmdsDefinitionsFile{}
+	switch format {
+		case CMDS_FORMAT_JSON:
+			if err := json.NewDecoder(reader).Decode(&definitions_file); err != nil {
+				return fmt.Errorf("invalid JSON commands definitions: %w", err)
+			}
+		case CMDS_FORMAT_YAML:
+			if err := yaml.NewDecoder(reader).Decode(&definitions_file); err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("invalid YAML commands definitions: %w", err)
+			}
+		default:
+			return fmt.Errorf("unknown commands definitions format: %q", format)
+	}
+
+	return detector.updateCmdsSet(func(cmds_set *cmdsSet) error {
+		cmds_set.cmds = nil
+
+		var errs []error = nil
+		var ids_found map[int]bool = make(map[int]bool)
+		for _, definition := range definitions_file.Commands {
+			if ids_found[definition.Id] {
+				errs = append(errs, &CmdDefinitionError{
+					Cmd_id:      definition.Id,
+					Field:       "id",
+					Description: "the ID is repeated on the definitions",
+				})
+
+				continue
+			}
+			ids_found[definition.Id] = true
+
+			var err error = nil
+			if cmds_set.cmds, err = addUpdateCmdDefinition(cmds_set.cmds, cmds_set.cmd_types, definition); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		return errors.Join(errs...)
+	})
+}
+
+/*
+ExportCmds exports the detector's commands to the JSON/YAML commands definitions format (check CmdDefinition), such
+that LoadCmdsFromReader() can load them back.
+
+-----------------------------------------------------------
+
+– Params:
+  - writer – the writer to write the definitions to
+  - format – the format of the definitions (one of the CMDS_FORMAT_-started constants)
+
+– Returns:
+  - an error if the definitions could not be written, nil otherwise
+*/
+func (detector *Detector) ExportCmds(writer io.Writer, format string) error {
+	var definitions_file cmdsDefinitionsFile = cmdsDefinitionsFile{}
+	for _, cmd := range detector.getCmdsSet().cmds {
+		definitions_file.Commands = append(definitions_file.Commands, cmd.definition)
+	}
+
+	switch format {
+		case CMDS_FORMAT_JSON:
+			var encoder *json.Encoder = json.NewEncoder(writer)
+			encoder.SetIndent("", "\t")
+
+			return encoder.Encode(definitions_file)
+		case CMDS_FORMAT_YAML:
+			var encoder *yaml.Encoder = yaml.NewEncoder(writer)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(definitions_file); err != nil {
+				return err
+			}
+
+			return encoder.Close()
+		default:
+			return fmt.Errorf("unknown commands definitions format: %q", format)
+	}
+}
@@ -26,24 +26,27 @@ package ACD
 //
 // --- WARNING ---
 // The command ID 0 is reserved for function-related processing!!! (Want to know for what? Read the comment about
-// MARK_TERMINATION_FLOAT32 on TaskChecker.) Negative values are also reserved for special commands.
+// MARK_TERMINATION on taskFilter().) Negative values are also reserved for special commands.
 //
-// Note: all RET_-started constants must be a float32 in a string which starts by the number on the corresponding
-// CMD_-started constant and must advance by increments of 0.00001 (means 1 000 000 sub-commands at most). The first
-// float can't end in .0 (99 then, not 100). No reason in specific, it's just in case it's ever needed to use the main
-// integer. So start for example with 1.00001 and 2.00001.
-
-// Minimum float precision of 32 bits for this - do NOT increase to 1 000 000. I tried it and the program started
-// malfunctioning a bit returning 0.000002 instead of 0.000001 in one case only, and the others returned normally. Not
-// sure why doesn't happen with 100k or below. Must always be a multiple of 10. Can't be 1M, must be 100k.
+// Note: the detected commands are kept internally as exact pairs of (command ID, condition index). Only when encoded to
+// the string returned by Main() they become the form "ID.VVVVV", in which VVVVV is the condition index+1 with
+// _SUB_CMD_DIGITS digits (trailing zeros removed) - so 1.00001 is the 1st condition of the command 1, and 1.0001 the
+// 10th. This used to be a float32, which lost the condition digits for big command IDs (100000+) - not anymore.
 
+// MAX_SUB_CMDS is the limit of the number of conditions (variations) of a command, as the string encoding only has
+// _SUB_CMD_DIGITS digits for them - a command can have up to MAX_SUB_CMDS-1 conditions (the condition index+1 is the
+// one encoded).
 const MAX_SUB_CMDS = 100_000
 
-var cmds_GL []commandInfo = nil
+// _SUB_CMD_DIGITS is the number of decimal digits used to encode the condition index on the Main() output.
+const _SUB_CMD_DIGITS int = 5
 
 // commandInfo represents a command that this module detects. For use with wordsVerificationFunction() - check the
 // meaning of each attribute there.
 type commandInfo struct {
+	// The definition the command was loaded from (kept to be able to export the command again)
+	definition CmdDefinition
+
 	// Positive (1+) integer
 	cmd_id     int
 	main_words []string
@@ -55,24 +58,26 @@ type commandInfo struct {
 				{ANY_MAIN_WORD},
 			},
 			{ // 14
+				{ANY_MAIN_WORD, "-fast"},
 				{"fast"},
 				{ANY_MAIN_WORD, "-fast"},
-				[the rest uses the last existing array here]
+				{ANY_MAIN_WORD, "-fast"},
 			},
 	*/
 	main_words_ret_conds [][]string
 
 	/*
-		Example of how it could be for some commands (it now includes the old conditions_continue and conditions_return):
+		Example of how it could be for some commands (it now includes the old conditions_continue and conditions_return),
+		with each condition written as {words groups} and each words group as {{allowed indexes}, {words}}:
 			{ // 4
 				{{{-1}, {"on"}}, {{-1}, {"wifi", "wi-fi"}}},
 				{{{-1}, {"off"}}, {{-1}, {"wifi", "wi-fi"}}},
 			},
 			{ // 14
-				{{{-1}, {"reboot", "restart"}}, {{-1}, {"device", "phone"}}},
-				{{{-1}, {"device", "phone"}}},
 				{{{-1}, {"device", "phone"}}, {{-1}, {"safe"}}, {{-1}, {"mode"}}},
+				{{{-1}, {"reboot", "restart"}}, {{-1}, {"device", "phone"}}},
 				{{{-1}, {"device", "phone"}}, {{-1}, {"recovery"}}},
+				{{{-1}, {"device", "phone"}}},
 			},
 			{ // 16
 				{{{-1}, {NONE, "rear"}}, {{-1}, {"video"}}},
@@ -80,14 +85,25 @@ type commandInfo struct {
 				{{{-1}, {"audio"}}},
 			},
 			{ // 17
-				{{-1}, {{"again", "said", "say"}}},
+				{{{-1}, {"again", "said", "say"}}},
 			},
 			{ // 19
-				{{{-1}, {"on"}}, {-1: {"battery", "power"}}, {{-1}, {"saver"}}},
-				{{{-1}, {"off"}}, {-1: {"battery", "power"}}, {{-1}, {"saver"}}},
+				{{{-1}, {"on"}}, {{-1}, {"battery", "power"}}, {{-1}, {"saver"}}},
+				{{{-1}, {"off"}}, {{-1}, {"battery", "power"}}, {{-1}, {"saver"}}},
 			},
 	*/
-	words_list [][][][]interface{}
+	words_list []cmdCondition
+
+	/*
+		The original variant (index of the condition on the definition's words_list) of each condition on words_list, as
+		the conditions are reordered when loaded to follow wordsVerificationFunction()'s ordering rule. Example for the
+		command 14 above (written as "reboot/restart device/phone|device/phone|device/phone safe mode|..."):
+			{2, 0, 3, 1}
+	*/
+	variants []int
+
+	// The part-of-speech tags the command gives to its words for the NLP analysis (check cmdTagOverrides())
+	tag_overrides map[string]string
 
 	left_intervs                     map[int]int
 	right_intervs                    map[int]int
@@ -98,8 +114,66 @@ type commandInfo struct {
 	exclude_mutually_exclusive_words bool
 }
 
-// Special WARN_-started commands returned by the sentenceCmdsDetector() - must not collide with spec_-started constants
-// on TaskChecker!!!
+// cmdCondition is a condition of a command - one of its variants. All its words groups must be found on the sentence for
+// the condition to be detected.
+type cmdCondition struct {
+	words_groups []wordsGroup
+}
+
+// wordsGroup is a group of words of a condition. The words are mutually exclusive: any of them is accepted as the word
+// of the group ("device" or "phone").
+type wordsGroup struct {
+	// allowed_indexes are the position indexes (sub-verifications) in which the group is accepted, or only
+	// ALL_SUB_VERIFS_INT to accept it in any position
+	allowed_indexes []int
+	// words are the words of the group. NONE makes the group optional and IS_DIGIT accepts any digit.
+	words []string
+}
+
+/*
+allowedAt checks if the words group is accepted in a position index.
+
+-----------------------------------------------------------
+
+– Params:
+  - sub_verification – the position index
+
+– Returns:
+  - true if it's accepted, false otherwise
+*/
+func (group wordsGroup) allowedAt(sub_verification int) bool {
+	if len(group.allowed_indexes) > 0 && group.allowed_indexes[0] == ALL_SUB_VERIFS_INT {
+		return true
+	}
+	for _, allowed_index := range group.allowed_indexes {
+		if allowed_index == sub_verification {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+isOptional checks if the words group is optional (has NONE).
+
+-----------------------------------------------------------
+
+– Returns:
+  - true if it's optional, false otherwise
+*/
+func (group wordsGroup) isOptional() bool {
+	for _, word := range group.words {
+		if word == NONE {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Special WARN_-started commands returned by the sentenceCmdsDetector() - must not collide with _SPEC_CMD_-started
+// constants on Main.go!!!
 
 // WARN_WHATS_IT is the constant that signals that an "it" was said but there seems to be nothing that it refers to, so
 // the assistant warns it didn't understand the meaning of the "it".
@@ -0,0 +1,383 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+CmdDefinitionError is a problem found on a command definition.
+*/
+type CmdDefinitionError struct {
+	// Cmd_id is the ID of the command with the problem (0 if the ID itself could not be read)
+	Cmd_id int
+	// Field is the name of the field with the problem (same as on the JSON/YAML definitions), plus the index of the
+	// element on it if it's a list
+	Field string
+	// Description is what is wrong with the field
+	Description string
+}
+
+func (err *CmdDefinitionError) Error() string {
+	return fmt.Sprintf("command %d, %s: %s", err.Cmd_id, err.Field, err.Description)
+}
+
+/*
+ValidateCmdDefinition calls Detector.ValidateCmdDefinition() on the default detector.
+*/
+func ValidateCmdDefinition(definition CmdDefinition) []error {
+	return default_detector_GL.ValidateCmdDefinition(definition)
+}
+
+/*
+ValidateCmdDefinition checks if a command definition is valid before loading it.
+
+It checks the ID, the types (which must be registered on the detector), the main words, the syntax of the main words
+return conditions and of the words list, and the other parameters. The order of the conditions doesn't matter, as
+they're reordered when loaded (check normalizeConditionsOrder()).
+
+-----------------------------------------------------------
+
+– Params:
+  - definition – the definition of the command
+
+– Returns:
+  - all the problems found on the definition, each one a *CmdDefinitionError, or nil if it's valid
+*/
+func (detector *Detector) ValidateCmdDefinition(definition CmdDefinition) []error {
+	return validateCmdDefinition(definition, detector.getCmdsSet().cmd_types)
+}
+
+/*
+validateCmdDefinition is the same as Detector.ValidateCmdDefinition(), but with the command types given directly.
+
+-----------------------------------------------------------
+
+– Params:
+  - definition – the definition of the command
+  - cmd_types – the command types that can be used on the command
+
+– Returns:
+  - same as in Detector.ValidateCmdDefinition()
+*/
+func validateCmdDefinition(definition CmdDefinition, cmd_types map[string]*CmdType) []error {
+	var errs []error = nil
+	var addError = func(field string, format string, args ...any) {
+		errs = append(errs, &CmdDefinitionError{
+			Cmd_id:      definition.Id,
+			Field:       field,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// id
+	if definition.Id <= 0 {
+		addError("id", "must be a positive number")
+	}
+
+	// types
+	var main_words []string = nil
+	var types []*CmdType = nil
+	var triggers_expansion bool = false
+	for i, type_str := range definition.Types {
+		cmd_type, ok := cmd_types[type_str]
+		if !ok {
+			addError(fmt.Sprintf("types[%d]", i), "unknown type %q (must be a CMDi_TYPE_-started constant or the "+
+				"name of a registered command type)", type_str)
+
+			continue
+		}
+		types = append(types, cmd_type)
+
+		if cmd_type.Expansion == CMD_TYPE_EXPAND_TRIGGERS {
+			triggers_expansion = true
+		}
+		main_words = append(main_words, cmd_type.Trigger_words...)
+	}
+
+	// main_words
+	for i, word := range definition.Main_words {
+		if !isValidPhrase(word) || strings.ContainsAny(word, " |/") || isSpecialCommand(word) {
+			addError(fmt.Sprintf("main_words[%d]", i), "%q is not a valid word", word)
+		}
+	}
+	main_words = append(main_words, definition.Main_words...)
+	if len(main_words) == 0 {
+		addError("main_words", "the command has no main words (neither from the types nor manual ones)")
+	}
+
+	// main_words_ret_conds
+	if triggers_expansion && len(definition.Main_words_ret_conds) > 0 {
+		addError("main_words_ret_conds", "not allowed with types expanded by the trigger words (each variant "+
+			"already has its trigger word as return condition)")
+	}
+	for i, condition_str := range definition.Main_words_ret_conds {
+		var field string = fmt.Sprintf("main_words_ret_conds[%d]", i)
+		if condition_str == "" {
+			addError(field, "empty condition")
+
+			continue
+		}
+
+		var any_main_word bool = false
+		var excludes_word bool = false
+		for _, word := range strings.Split(condition_str, " ") {
+			switch {
+				case word == "":
+					addError(field, "%q has an empty word (words must be separated by one space)", condition_str)
+				case word == ANY_MAIN_WORD:
+					any_main_word = true
+				case isSpecialCommand(word):
+					addError(field, "%q is not allowed (the only special word allowed is %q)", word, ANY_MAIN_WORD)
+				case strings.HasPrefix(word, "-"):
+					excludes_word = true
+					if !isInSlice(main_words, word[1:]) {
+						addError(field, "%q excludes %q, which is not a main word", condition_str, word[1:])
+					}
+				default:
+					if !isInSlice(main_words, word) {
+						addError(field, "%q is not a main word", word)
+					}
+			}
+		}
+		if excludes_word && !any_main_word {
+			addError(field, "%q excludes main words but doesn't have %q to exclude them from", condition_str,
+				ANY_MAIN_WORD)
+		}
+	}
+
+	// words_list
+	if len(definition.Words_list) == 0 {
+		addError("words_list", "the command has no conditions")
+	}
+	for i, condition_str := range definition.Words_list {
+		var field string = fmt.Sprintf("words_list[%d]", i)
+		if condition_str == "" {
+			addError(field, "empty condition")
+
+			continue
+		}
+		if strings.Contains(condition_str, "|") {
+			addError(field, "%q has a \"|\" (each condition must be a separate element)", condition_str)
+
+			continue
+		}
+
+		for _, words_group_str := range strings.Split(condition_str, " ") {
+			words_group, err := parseWordsGroup(words_group_str)
+			if err != nil {
+				addError(field, "%v", err)
+			} else if definition.Exclude_main_words && onlyMainWords(words_group, main_words) {
+				// The main words are removed from the groups when the command is loaded, so the group would be left
+				// without words and the condition could never be detected.
+				addError(field, "%q only has main words, which exclude_main_words removes", words_group_str)
+			}
+		}
+	}
+	// The conditions after the types expand them (the list is extended to the number of return conditions when loaded)
+	// - their index+1 must fit in the _SUB_CMD_DIGITS digits of the returned commands.
+	var num_conditions int = len(definition.Words_list)
+	if len(definition.Main_words_ret_conds) > num_conditions {
+		num_conditions = len(definition.Main_words_ret_conds)
+	}
+	if expandedConditionsCount(types, num_conditions) > MAX_SUB_CMDS-1 {
+		addError("words_list", "the command has more than %d conditions (after its types expand them)",
+			MAX_SUB_CMDS-1)
+	}
+
+	// left_intervs and right_intervs
+	for _, intervs := range []struct {
+		field string
+		intervs map[string]int
+	}{
+		{"left_intervs", definition.Left_intervs},
+		{"right_intervs", definition.Right_intervs},
+	} {
+		for _, key := range sortedKeys(intervs.intervs) {
+			var interv int = intervs.intervs[key]
+			var field string = fmt.Sprintf("%s[%s]", intervs.field, key)
+			sub_verif, err := parseSubVerifKey(key)
+			if err != nil || (sub_verif < 0 && sub_verif != ALL_SUB_VERIFS_INT && sub_verif != INDEX_EVEN &&
+				sub_verif != INDEX_ODD) {
+				addError(field, "the key must be a sub-verification number or one of ALL_SUB_VERIFS_INT (%d or "+
+					"\"all\"), INDEX_EVEN (%d or \"even\") or INDEX_ODD (%d or \"odd\")", ALL_SUB_VERIFS_INT,
+					INDEX_EVEN, INDEX_ODD)
+			}
+			if interv < 0 && interv != DEFAULT_INDEX {
+				addError(field, "%d is not a valid interval (must be 0 or more, or DEFAULT_INDEX (%d))", interv,
+					DEFAULT_INDEX)
+			}
+		}
+	}
+
+	// init_indexes_sub_verifs
+	for _, key := range sortedKeys(definition.Init_indexes_sub_verifs) {
+		var init_index string = definition.Init_indexes_sub_verifs[key]
+		var field string = fmt.Sprintf("init_indexes_sub_verifs[%s]", key)
+		sub_verif, err := parseSubVerifKey(key)
+		if err != nil || (sub_verif < 0 && sub_verif != ALL_SUB_VERIFS_INT) {
+			addError(field, "the key must be a sub-verification number or ALL_SUB_VERIFS_INT (%d or \"all\")",
+				ALL_SUB_VERIFS_INT)
+		}
+		if !isValidInitIndex(init_index) {
+			addError(field, "%q is not a valid index (must be a number, %q, or %q optionally followed by +N or -N)",
+				init_index, INDEX_DEFAULT, INDEX_WORD_FOUND)
+		}
+	}
+
+	// exclude_word_found_group
+	var exclude_word_found_group []int = nil
+	if definition.Exclude_word_found_group != nil {
+		exclude_word_found_group = *definition.Exclude_word_found_group
+	}
+	for i, sub_verif := range exclude_word_found_group {
+		if sub_verif < 0 && (sub_verif != ALL_SUB_VERIFS_INT || i != 0) {
+			addError(fmt.Sprintf("exclude_word_found_group[%d]", i), "%d is not a valid sub-verification number "+
+				"(ALL_SUB_VERIFS_INT (%d) can only be the first element)", sub_verif, ALL_SUB_VERIFS_INT)
+		}
+	}
+
+	return errs
+}
+
+/*
+onlyMainWords checks if all the words of a words group are main words (as corrected when loaded - check
+correctedMainWord()).
+
+-----------------------------------------------------------
+
+– Params:
+  - words_group – the words group
+  - main_words – the main words of the command
+
+– Returns:
+  - true if the group has words and all of them are main words, false otherwise
+*/
+func onlyMainWords(words_group wordsGroup, main_words []string) bool {
+	var corrected_main_words []string = make([]string, 0, len(main_words))
+	for _, main_word := range main_words {
+		corrected_main_words = append(corrected_main_words, correctedMainWord(main_word))
+	}
+
+	return len(words_group.words) > 0 && len(removeWords(words_group.words, func(word string) bool {
+		return isInSlice(corrected_main_words, word)
+	})) == 0
+}
+
+/*
+isValidInitIndex checks if a value of 'init_indexes_sub_verifs' is valid.
+
+-----------------------------------------------------------
+
+– Params:
+  - init_index – the value
+
+– Returns:
+  - true if it's a non-negative number, INDEX_DEFAULT, or INDEX_WORD_FOUND optionally followed by +N or -N, false
+    otherwise
+*/
+func isValidInitIndex(init_index string) bool {
+	if init_index == INDEX_DEFAULT || init_index == INDEX_WORD_FOUND {
+		return true
+	}
+	if strings.HasPrefix(init_index, INDEX_WORD_FOUND) {
+		var offset string = init_index[len(INDEX_WORD_FOUND):]
+		if !strings.HasPrefix(offset, "+") && !strings.HasPrefix(offset, "-") {
+			return false
+		}
+		_, err := strconv.Atoi(offset[1:])
+
+		return err == nil
+	}
+	index, err := strconv.Atoi(init_index)
+
+	return err == nil && index >= 0
+}
+
+/*
+isValidPhrase checks if a main word has no empty words on it (the words of phrases are separated by
+MAIN_WORDS_PHRASE_SEP, like "good_night").
+
+-----------------------------------------------------------
+
+– Params:
+  - main_word – the main word
+
+– Returns:
+  - true if none of its words is empty, false otherwise
+*/
+func isValidPhrase(main_word string) bool {
+	for _, word := range strings.Split(main_word, MAIN_WORDS_PHRASE_SEP) {
+		if word == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+isInSlice checks if a string is on a slice.
+
+-----------------------------------------------------------
+
+– Params:
+  - slice – the slice
+  - str – the string
+
+– Returns:
+  - true if it's on the slice, false otherwise
+*/
+func isInSlice(slice []string, str string) bool {
+	for _, elem := range slice {
+		if elem == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+sortedKeys gets the keys of a map sorted, to go through the map always in the same order.
+
+-----------------------------------------------------------
+
+– Params:
+  - map_ – the map
+
+– Returns:
+  - the sorted keys
+*/
+func sortedKeys[T any](map_ map[string]T) []string {
+	var keys []string = nil
+	for key := range map_ {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,287 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DetectionWarning is the type of the warnings that can be returned in a Detection.
+type DetectionWarning int
+
+const (
+	// WARNING_NONE means the detection is a normal command and not a warning.
+	WARNING_NONE DetectionWarning = iota
+	// WARNING_WHATS_IT is the typed version of WARN_WHATS_IT.
+	WARNING_WHATS_IT
+	// WARNING_WHATS_AND is the typed version of WARN_WHATS_AND.
+	WARNING_WHATS_AND
+)
+
+// CmdContext is the information about the previous command(s) that Detect() uses to know the meaning of an "it" or an
+// "and" that has nothing to refer to on the sentence itself, and which it also returns for use on the next calls. It's
+// the structured version of the "last name|last action|last names" part of the Main() input and output.
+type CmdContext struct {
+	// Last_name is the last name detected on the sentence (can be more than one word, like "airplane mode")
+	Last_name string
+	// Last_action is the last action detected on the sentence (like "turn on the" for the "wifi")
+	Last_action string
+	// Last_names are the names of the last noun phrases coordinated by "and"s or "or"s on the sentence, if there are
+	// more than one (like "wifi" and "airplane mode" on "the wifi and the airplane mode"), for a "them" to refer to
+	Last_names []string
+}
+
+// DetectOptions are the options for Detect().
+type DetectOptions struct {
+	// Remove_repet_cmds is the same as 'remove_repet_cmds' in Main()
+	Remove_repet_cmds bool
+	// Invalidate_detec_words is the same as 'invalidate_detec_words' in Main()
+	Invalidate_detec_words bool
+	// Optimal_segmentation is true to choose the detected commands among all the possible ones, instead of detecting them
+	// from left to right with each one taking its words from the next ones - check sentenceCmdsDetectorOptimal(). With
+	// it, 'Invalidate_detec_words' is ignored (the chosen commands never share words).
+	Optimal_segmentation bool
+	// Confirmation_threshold is the score below which the detections are marked as needing confirmation (from 0 to 1 -
+	// 0 to never mark them)
+	Confirmation_threshold float64
+	// Report_ambiguities is true to fill Detection.Candidates when the detection was chosen among others as good as it
+	Report_ambiguities bool
+	// Report_near_misses is true to fill DetectionResult.Near_misses
+	Report_near_misses bool
+	// Trace is true to collect what the detection did, step by step, on DetectionResult.Trace (for debugging)
+	Trace bool
+	// Prev_cmd_context is the context returned on the previous call, or an empty one if there's none
+	Prev_cmd_context CmdContext
+}
+
+// Detection is a command or a warning detected on a sentence.
+type Detection struct {
+	// Cmd_id is the ID of the detected command, or 0 if this is a warning
+	Cmd_id int
+	// Sub_cmd_index is the index of the detected variation of the command (the same as returned by GetSubCmdIndex()),
+	// or -1 if this is a warning
+	Sub_cmd_index int
+	// Warning is the warning detected, or WARNING_NONE if this is a normal command
+	Warning DetectionWarning
+	// Score is the confidence on the detection, from 0 to 1 (1 for warnings). It's lower the more optional words groups
+	// were not found, the farther apart the words found were (relative to the search intervals), if the main word was
+	// a trigger word of a type instead of a manual one, and if the NLP analysis put words on the sentence that were
+	// used ("it" and "and" replaced by their meanings).
+	Score float64
+	// Needs_confirmation is true if the 'Score' is below the DetectOptions.Confirmation_threshold - for example to ask
+	// for a confirmation before acting on the detection
+	Needs_confirmation bool
+	// Main_word_span is the part of the original sentence with the main word that triggered the detection (or with the
+	// "it" or "and" that has no meaning, for warnings). Words put there by the NLP analysis (the meaning of an "it" or
+	// an "and") have the span of the "it" or "and", and corrected words of the words corrected ("what's" of "what is").
+	Main_word_span TextSpan
+	// Words_spans are the parts of the original sentence with the words found for the detected variation, in the order
+	// of the sentence (the same way as 'Main_word_span'), or nil for warnings
+	Words_spans []TextSpan
+	// Candidates are, with DetectOptions.Report_ambiguities, all the commands and variations that were tied for the
+	// detection (the detection included, as the first of them): the ones triggered by the same main word with as many
+	// words found. nil if there was no tie (or without the option). For example to ask "did you mean X or Y?" instead
+	// of acting on the detection.
+	Candidates []DetectionCandidate
+}
+
+// DetectionCandidate is one of the commands and variations tied for a detection - check Detection.Candidates.
+type DetectionCandidate struct {
+	Cmd_id        int
+	Sub_cmd_index int
+	// Words are the words found for the variation (as on the sentence after the corrections and the NLP analysis)
+	Words []string
+	// Main_word_span is the same as in Detection
+	Main_word_span TextSpan
+	// Words_spans is the same as in Detection
+	Words_spans []TextSpan
+}
+
+// DetectionResult is what Detect() returns.
+type DetectionResult struct {
+	// Detections is the list of the detected commands and warnings, in the order they appear on the sentence
+	Detections []Detection
+	// Cmd_context is the context to give to the next call (the same as the "last name|last action|" part of Main())
+	Cmd_context CmdContext
+	// Unconsumed are the parts of the original sentence that no detection used (what was not understood), in order
+	Unconsumed []UnconsumedSegment
+	// Near_misses are, with DetectOptions.Report_near_misses, the commands triggered by main words from which nothing
+	// was detected (like the ones of "turn on the" or "record"), with what was missing - for example to ask "turn on
+	// what?"
+	Near_misses []NearMiss
+	// Trace is what the detection did, step by step, if DetectOptions.Trace was set, or nil otherwise
+	Trace *DetectionTrace
+}
+
+// NearMiss is a command whose main word was said, but of which no variation had all its words found.
+type NearMiss struct {
+	Cmd_id int
+	// Main_word_span is the span on the original sentence of the main word that triggered the command
+	Main_word_span TextSpan
+	// Variations are the variations with the most words found (of the ones accepted for the main word)
+	Variations []NearMissVariation
+}
+
+// NearMissVariation is a variation of a NearMiss.
+type NearMissVariation struct {
+	Sub_cmd_index int
+	// Words are the words found for the variation (as on the sentence after the corrections and the NLP analysis)
+	Words []string
+	// Words_spans are the spans on the original sentence of the 'Words' (the same way as in Detection)
+	Words_spans []TextSpan
+	// Missing_groups are the words groups not found, each with its words (any of them would do, like {"on", "off"} -
+	// and IS_DIGIT for any digit)
+	Missing_groups [][]string
+}
+
+// UnconsumedSegment is a part of the original sentence that no detection used - for example "tell me a joke" on "turn
+// on the wifi and tell me a joke", to give to some other module to handle.
+type UnconsumedSegment struct {
+	// Span is where the part is on the original sentence
+	Span TextSpan
+	// Text is the part of the original sentence (sentence_str[Span.Start:Span.End])
+	Text string
+}
+
+/*
+String returns the detection in the form used by Main() - for example "4.00001" or "-10".
+
+The encoding is exact (no floats involved): the command ID, a dot, and the index+1 of the variation with 5 digits and
+without the trailing zeros.
+*/
+func (detection Detection) String() string {
+	switch detection.Warning {
+		case WARNING_WHATS_IT:
+			return WARN_WHATS_IT
+		case WARNING_WHATS_AND:
+			return WARN_WHATS_AND
+	}
+
+	return strconv.Itoa(detection.Cmd_id) + "." +
+		strings.TrimRight(fmt.Sprintf("%0*d", _SUB_CMD_DIGITS, detection.Sub_cmd_index+1), "0")
+}
+
+/*
+newDetection creates a Detection from a command returned by sentenceCmdsDetector().
+
+-----------------------------------------------------------
+
+– Params:
+  - command – the command to convert
+
+– Returns:
+  - the corresponding Detection
+*/
+func newDetection(command detectedCmd) Detection {
+	switch strconv.Itoa(command.cmd_id) {
+		case WARN_WHATS_IT:
+			return Detection{Sub_cmd_index: -1, Warning: WARNING_WHATS_IT, Score: command.score,
+				Main_word_span: command.main_word_span}
+		case WARN_WHATS_AND:
+			return Detection{Sub_cmd_index: -1, Warning: WARNING_WHATS_AND, Score: command.score,
+				Main_word_span: command.main_word_span}
+	}
+
+	return Detection{
+		Cmd_id:         command.cmd_id,
+		Sub_cmd_index:  command.sub_cmd_index,
+		Warning:        WARNING_NONE,
+		Score:          command.score,
+		Main_word_span: command.main_word_span,
+		Words_spans:    command.words_spans,
+		Candidates:     command.candidates,
+	}
+}
+
+/*
+unconsumedSegments gets the parts of the original sentence that no detection used.
+
+A word counts as used if it's anywhere from the first to the last word used by a detection (so the "the" of "turn on
+the wifi" is used too), including the detections of special commands and the ones deleted by them (they were understood
+too). The words deleted by the NLP analysis (like the "and" on "turn on the wifi and tell me a joke") and the ones it put
+on the sentence that were not used (like the meaning of the "and" on "turn on the wifi and also some music") don't begin
+nor end any part.
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence_str – the original sentence
+  - words_origins – the origins of the words of the sentence on which the commands were detected
+  - sentence_cmds – all the commands detected on the sentence, before taskFilter()
+
+– Returns:
+  - the parts not used, in the order of the sentence
+*/
+func unconsumedSegments(sentence_str string, words_origins []wordOrigin,
+	sentence_cmds []detectedCmd) []UnconsumedSegment {
+	// The parts of the original sentence used by each detection.
+	var used_spans []TextSpan = nil
+	for _, command := range sentence_cmds {
+		var used_span TextSpan = command.main_word_span
+		for _, span := range command.words_spans {
+			if span.Start < used_span.Start {
+				used_span.Start = span.Start
+			}
+			if span.End > used_span.End {
+				used_span.End = span.End
+			}
+		}
+		used_spans = append(used_spans, used_span)
+	}
+	var isUsed = func(span TextSpan) bool {
+		for _, used_span := range used_spans {
+			if span.Start >= used_span.Start && span.End <= used_span.End {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var segments []UnconsumedSegment = nil
+	var segment_open bool = false
+	for _, word_origin := range words_origins {
+		if word_origin.span.Start == word_origin.span.End {
+			// Empty words (from repeated spaces) and words only added to the sentence don't count.
+			continue
+		}
+
+		if isUsed(word_origin.span) {
+			segment_open = false
+		} else if word_origin.substituted {
+			// Only inside a part (if a word after it isn't used either).
+			continue
+		} else if segment_open && word_origin.span.End > segments[len(segments)-1].Span.End {
+			segments[len(segments)-1].Span.End = word_origin.span.End
+		} else if !segment_open {
+			segments = append(segments, UnconsumedSegment{Span: word_origin.span})
+			segment_open = true
+		}
+	}
+	for i := range segments {
+		segments[i].Text = sentence_str[segments[i].Span.Start:segments[i].Span.End]
+	}
+
+	return segments
+}
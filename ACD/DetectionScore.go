@@ -0,0 +1,105 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+// How much each part of the score of a detection can take from it (the score begins at 1). Check detectionScore().
+
+// _SCORE_WEIGHT_OPTIONAL is taken in proportion to the optional words groups of the condition that were not found.
+const _SCORE_WEIGHT_OPTIONAL float64 = 0.1
+// _SCORE_WEIGHT_DISTANCE is taken in proportion to the average distance of the words found to where their search
+// began, relative to the search interval (a word right next to the previous one takes nothing, one on the edge of the
+// interval takes all).
+const _SCORE_WEIGHT_DISTANCE float64 = 0.3
+// _SCORE_WEIGHT_TYPE_TRIGGER is taken if the main word was a trigger word of a type of the command (a generic verb,
+// like "turn") and not a manual main word.
+const _SCORE_WEIGHT_TYPE_TRIGGER float64 = 0.1
+// _SCORE_WEIGHT_NLP is taken if any of the words used were put on the sentence by the NLP analysis (the meaning of an
+// "it" or an "and").
+const _SCORE_WEIGHT_NLP float64 = 0.3
+
+/*
+detectionScore calculates the score of a detection: the confidence on it, from 0 to 1.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd – the detected command
+  - condition – the index of the detected condition on the command's 'words_list'
+  - condition_match – the match of the condition, from wordsVerificationFunction()
+  - main_word_match – the main word that triggered the detection
+  - main_word_index – the index on the sentence of the first word of the main word
+  - words_origins – same as in sentenceCmdsDetector()
+
+– Returns:
+  - the score
+*/
+func detectionScore(cmd commandInfo, condition int, condition_match conditionMatch, main_word_match mainWordMatch,
+	main_word_index int, words_origins []wordOrigin) float64 {
+	var score float64 = 1
+
+	var num_optional int = 0
+	for _, words_group := range cmd.words_list[condition].words_groups {
+		if words_group.isOptional() {
+			num_optional++
+		}
+	}
+	var num_optional_missing int = 0
+	var num_words_found int = 0
+	var distances_sum float64 = 0
+	var used_indexes []int = nil
+	for index := main_word_index; index <= main_word_match.end_index; index++ {
+		used_indexes = append(used_indexes, index)
+	}
+	for _, word_match := range condition_match.words_matches {
+		if word_match.word == NONE {
+			num_optional_missing++
+
+			continue
+		}
+
+		num_words_found++
+		used_indexes = append(used_indexes, word_match.index)
+		if word_match.window > 1 {
+			// The next word is at distance 1, so that's the best case.
+			distances_sum += float64(word_match.distance-1) / float64(word_match.window-1)
+		}
+	}
+
+	if num_optional > 0 {
+		score -= _SCORE_WEIGHT_OPTIONAL * float64(num_optional_missing) / float64(num_optional)
+	}
+	if num_words_found > 0 {
+		score -= _SCORE_WEIGHT_DISTANCE * distances_sum / float64(num_words_found)
+	}
+	if !isInSlice(cmd.definition.Main_words, main_word_match.main_word) {
+		score -= _SCORE_WEIGHT_TYPE_TRIGGER
+	}
+	for _, index := range used_indexes {
+		if index < len(words_origins) && words_origins[index].substituted {
+			score -= _SCORE_WEIGHT_NLP
+
+			break
+		}
+	}
+
+	return score
+}
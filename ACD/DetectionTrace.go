@@ -0,0 +1,358 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DetectionTrace is what Detect() did on a sentence, step by step - for debugging the detections. It's only collected
+// with DetectOptions.Trace, and String() renders it as text.
+type DetectionTrace struct {
+	// Corrected_sentence is the sentence after the corrections (sentenceCorrection()), before the NLP analysis
+	Corrected_sentence []string
+	// Pos_tags are the part-of-speech tags given to the words by the NLP analysis
+	Pos_tags []TraceTag
+	// Substitutions are the "it"s and "and"s replaced by their meanings or deleted by the NLP analysis, in order
+	Substitutions []TraceSubstitution
+	// Detection_sentence is the sentence on which the commands were detected (after the NLP analysis)
+	Detection_sentence []string
+	// Main_words are the main words found on the 'Detection_sentence', which triggered the verification of commands
+	Main_words []TraceMainWord
+	// Conditions are the conditions tried for each command triggered, with the words matched
+	Conditions []TraceCondition
+	// Ret_cond_checks are the checks of the main words return conditions done after each command's conditions were
+	// tried
+	Ret_cond_checks []TraceRetCondCheck
+	// Filtered are the commands deleted by the special commands filter (like "don't" and "never mind")
+	Filtered []TraceFilteredCmd
+}
+
+// TraceTag is a word and its part-of-speech tag.
+type TraceTag struct {
+	Word string
+	Tag  string
+}
+
+// TraceSubstitution is an "it" or an "and" replaced or deleted by the NLP analysis.
+type TraceSubstitution struct {
+	// Index is the index of the word on the sentence at the time of the substitution
+	Index int
+	// Word is the word replaced or deleted ("it" or "and")
+	Word string
+	// Replacement are the words the 'Word' was replaced by, or nil if it was deleted
+	Replacement []string
+	// Reason is why it was replaced or deleted
+	Reason string
+}
+
+// TraceMainWord is a main word found on the sentence.
+type TraceMainWord struct {
+	// Index is the index on the sentence of the (first word of the) main word
+	Index int
+	// Main_word is the main word found (the whole phrase, for phrases - like "good_night")
+	Main_word string
+	// Cmd_ids are the IDs of the commands triggered by the main word
+	Cmd_ids []int
+}
+
+// TraceCondition is a condition of a command tried after a main word triggered it.
+type TraceCondition struct {
+	Cmd_id int
+	// Main_word_index is the index on the sentence of the main word that triggered the command
+	Main_word_index int
+	// Condition is the index of the condition (the variation) on the command definition
+	Condition int
+	// Words are the words matched for each words group checked, in order
+	Words []TraceWordMatch
+	// All_found is true if all the words groups of the condition were found
+	All_found bool
+}
+
+// TraceWordMatch is the word matched for a words group of a condition.
+type TraceWordMatch struct {
+	// Word is the word matched, NONE if an optional group wasn't found, or "" if a mandatory group wasn't found
+	Word string
+	// Index is the index of the word on the sentence, or -1 if it wasn't found
+	Index int
+}
+
+// TraceRetCondCheck is the check of the main words return conditions of a command.
+type TraceRetCondCheck struct {
+	Cmd_id          int
+	Main_word       string
+	Main_word_index int
+	// Condition is the index of the condition (the variation) accepted, or -1 if none was (no detection)
+	Condition int
+}
+
+// TraceFilteredCmd is a command deleted by the special commands filter.
+type TraceFilteredCmd struct {
+	// Index is the index of the command on the list of the detected commands, before the filter
+	Index int
+	// Cmd_id is the ID of the command, or the number of the special command or warning
+	Cmd_id int
+	// Sub_cmd_index is the index of the condition (the variation) of the command, or -1 for special commands
+	Sub_cmd_index int
+	// Reason is why the command was deleted
+	Reason string
+}
+
+/*
+String renders the trace as text, one step per section.
+*/
+func (trace *DetectionTrace) String() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "Corrected sentence: %s\n", strings.Join(trace.Corrected_sentence, " "))
+
+	var pos_tags []string = nil
+	for _, pos_tag := range trace.Pos_tags {
+		pos_tags = append(pos_tags, pos_tag.Word+"/"+pos_tag.Tag)
+	}
+	fmt.Fprintf(&builder, "POS tags: %s\n", strings.Join(pos_tags, " "))
+
+	builder.WriteString("Substitutions:\n")
+	for _, substitution := range trace.Substitutions {
+		var replacement string = "deleted"
+		if substitution.Replacement != nil {
+			replacement = fmt.Sprintf("%q", strings.Join(substitution.Replacement, " "))
+		}
+		fmt.Fprintf(&builder, "  [%d] %q --> %s (%s)\n", substitution.Index, substitution.Word, replacement,
+			substitution.Reason)
+	}
+
+	fmt.Fprintf(&builder, "Detection sentence: %s\n", strings.Join(trace.Detection_sentence, " "))
+
+	builder.WriteString("Main words:\n")
+	for _, main_word := range trace.Main_words {
+		fmt.Fprintf(&builder, "  [%d] %q --> commands %s\n", main_word.Index, main_word.Main_word,
+			strings.Trim(fmt.Sprint(main_word.Cmd_ids), "[]"))
+	}
+
+	builder.WriteString("Conditions:\n")
+	for _, condition := range trace.Conditions {
+		var words []string = nil
+		for _, word_match := range condition.Words {
+			switch word_match.Word {
+				case "":
+					words = append(words, "(not found)")
+				case NONE:
+					words = append(words, "(optional, not found)")
+				default:
+					words = append(words, fmt.Sprintf("%q [%d]", word_match.Word, word_match.Index))
+			}
+		}
+		var result string = "not found"
+		if condition.All_found {
+			result = "found"
+		}
+		fmt.Fprintf(&builder, "  command %d [%d], condition %d: %s --> %s\n", condition.Cmd_id,
+			condition.Main_word_index, condition.Condition, strings.Join(words, ", "), result)
+	}
+
+	builder.WriteString("Return conditions:\n")
+	for _, check := range trace.Ret_cond_checks {
+		var result string = "no detection"
+		if check.Condition != -1 {
+			result = fmt.Sprintf("condition %d", check.Condition)
+		}
+		fmt.Fprintf(&builder, "  command %d, main word %q [%d] --> %s\n", check.Cmd_id, check.Main_word,
+			check.Main_word_index, result)
+	}
+
+	builder.WriteString("Filtered:\n")
+	for _, filtered := range trace.Filtered {
+		fmt.Fprintf(&builder, "  [%d] %s: %s\n", filtered.Index, traceCmdName(filtered.Cmd_id, filtered.Sub_cmd_index),
+			filtered.Reason)
+	}
+
+	return builder.String()
+}
+
+/*
+traceCmdName gets the name of a detected command for the trace text.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd_id – the ID of the command, or the number of the special command or warning
+  - sub_cmd_index – the index of the condition of the command
+
+– Returns:
+  - the command as returned by Main() (like "4.00001" or "-10"), or the special command ("don't" or "never mind")
+*/
+func traceCmdName(cmd_id int, sub_cmd_index int) string {
+	switch cmd_id {
+		case _SPEC_CMD_DONT:
+			return "\"don't\""
+		case _SPEC_CMD_NEVER_MIND:
+			return "\"never mind\""
+	}
+
+	return newDetection(detectedCmd{cmd_id: cmd_id, sub_cmd_index: sub_cmd_index}).String()
+}
+
+// The functions below record the steps on the trace. They do nothing on a nil trace, which is what is given to the
+// detection functions when the trace is not wanted.
+
+/*
+addSubstitution records a substitution done by the NLP analysis.
+
+-----------------------------------------------------------
+
+– Params:
+  - index – the index of the word on the sentence
+  - word – the word replaced or deleted
+  - replacement – the words it was replaced by, or nil if it was deleted
+  - reason – why it was replaced or deleted
+
+– Returns:
+  - nothing
+*/
+func (trace *DetectionTrace) addSubstitution(index int, word string, replacement []string, reason string) {
+	if trace == nil {
+		return
+	}
+
+	trace.Substitutions = append(trace.Substitutions, TraceSubstitution{
+		Index:       index,
+		Word:        word,
+		Replacement: append([]string(nil), replacement...),
+		Reason:      reason,
+	})
+}
+
+/*
+addMainWords records the main words found on an index of the sentence.
+
+-----------------------------------------------------------
+
+– Params:
+  - index – the index on the sentence
+  - main_word_matches – the main words found on the index
+  - cmds – the commands of the commands set
+
+– Returns:
+  - nothing
+*/
+func (trace *DetectionTrace) addMainWords(index int, main_word_matches []mainWordMatch, cmds []commandInfo) {
+	if trace == nil {
+		return
+	}
+
+	for _, main_word_match := range main_word_matches {
+		var cmd_ids []int = nil
+		for _, i := range main_word_match.cmds_indexes {
+			cmd_ids = append(cmd_ids, cmds[i].cmd_id)
+		}
+		trace.Main_words = append(trace.Main_words, TraceMainWord{
+			Index:     index,
+			Main_word: main_word_match.main_word,
+			Cmd_ids:   cmd_ids,
+		})
+	}
+}
+
+/*
+addVerification records the conditions of a command tried after a main word triggered it, and the check of the return
+conditions done on them.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd – the command
+  - main_word – the main word that triggered the command
+  - main_word_index – the index on the sentence of the main word
+  - results – the return of wordsVerificationFunction()
+  - final_cond – the return of checkMainWordsRetConds()
+
+– Returns:
+  - nothing
+*/
+func (trace *DetectionTrace) addVerification(cmd commandInfo, main_word string, main_word_index int,
+	results []conditionMatch, final_cond int) {
+	if trace == nil {
+		return
+	}
+
+	for condition, condition_match := range results {
+		var words []TraceWordMatch = nil
+		for _, word_match := range condition_match.words_matches {
+			var trace_word_match TraceWordMatch = TraceWordMatch{
+				Word:  word_match.word,
+				Index: word_match.index,
+			}
+			if !word_match.found {
+				trace_word_match.Word = ""
+			} else if word_match.index < 0 {
+				trace_word_match.Word = NONE
+			}
+			words = append(words, trace_word_match)
+		}
+		trace.Conditions = append(trace.Conditions, TraceCondition{
+			Cmd_id:          cmd.cmd_id,
+			Main_word_index: main_word_index,
+			Condition:       cmd.variants[condition],
+			Words:           words,
+			All_found:       condition_match.allFound(),
+		})
+	}
+
+	var check TraceRetCondCheck = TraceRetCondCheck{
+		Cmd_id:          cmd.cmd_id,
+		Main_word:       main_word,
+		Main_word_index: main_word_index,
+		Condition:       -1,
+	}
+	if final_cond != -1 {
+		check.Condition = cmd.variants[final_cond]
+	}
+	trace.Ret_cond_checks = append(trace.Ret_cond_checks, check)
+}
+
+/*
+addFiltered records a command deleted by the special commands filter.
+
+-----------------------------------------------------------
+
+– Params:
+  - index – the index of the command on the list of the detected commands
+  - command – the command
+  - reason – why it was deleted
+
+– Returns:
+  - nothing
+*/
+func (trace *DetectionTrace) addFiltered(index int, command detectedCmd, reason string) {
+	if trace == nil {
+		return
+	}
+
+	trace.Filtered = append(trace.Filtered, TraceFilteredCmd{
+		Index:         index,
+		Cmd_id:        command.cmd_id,
+		Sub_cmd_index: command.sub_cmd_index,
+		Reason:        reason,
+	})
+}
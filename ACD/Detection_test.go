@@ -0,0 +1,104 @@
+/*******************************************************************************
+ * Copyright 2023-2024 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+/*
+BenchmarkCmdsDetection measures only the commands detection (no corrections nor NLP analysis, which take much longer)
+with 10, 100 and 1000 commands. The time should barely change with the number of commands, as only the commands of the
+main words on the sentence are verified.
+*/
+func BenchmarkCmdsDetection(b *testing.B) {
+	// The sentence triggers 3 commands of the synthetic ones (whatever their number), plus a few words that are main
+	// words of none.
+	const sentence_str string = "please verb5 the object5 now and then verb7 thing7 and do9 the thing9"
+	const exp_cmds string = "5.00001, 7.00002, 9.00002"
+
+	for _, num_cmds := range []int{10, 100, 1000} {
+		b.Run(strconv.Itoa(num_cmds)+"_cmds", func(b *testing.B) {
+			var detector *Detector = NewDetector()
+			if err := detector.ReloadCmdsArray(syntheticCmds(num_cmds)); err != nil {
+				b.Fatal("the synthetic commands were not loaded:", err)
+			}
+			if cmds := cmdsDetectionOnly(detector, sentence_str); cmds != exp_cmds {
+				b.Fatalf("%d commands: %q detected instead of %q", num_cmds, cmds, exp_cmds)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cmdsDetectionOnly(detector, sentence_str)
+			}
+		})
+	}
+}
+
+/*
+cmdsDetectionOnly runs only the commands detection on a sentence that is already prepared (lowercase words separated by
+one space).
+
+-----------------------------------------------------------
+
+– Params:
+  - detector – the detector
+  - sentence_str – the sentence
+
+– Returns:
+  - the detected commands, separated by CMDS_SEPARATOR
+*/
+func cmdsDetectionOnly(detector *Detector, sentence_str string) string {
+	var sentence_cmds []detectedCmd = sentenceCmdsDetector(detector.getCmdsSet(), strings.Split(sentence_str, " "),
+		nil, true, false, nil, nil)
+
+	var detected_commands []string = nil
+	for _, command := range sentence_cmds {
+		detected_commands = append(detected_commands, newDetection(command).String())
+	}
+
+	return strings.Join(detected_commands, CMDS_SEPARATOR)
+}
+
+/*
+syntheticCmds generates commands for the benchmarks, each with its own main words and words.
+
+-----------------------------------------------------------
+
+– Params:
+  - num_cmds – the number of commands
+
+– Returns:
+  - the commands in the format of ReloadCmdsArray()
+*/
+func syntheticCmds(num_cmds int) string {
+	var cmds []string = nil
+	for id := 1; id <= num_cmds; id++ {
+		var n string = strconv.Itoa(id)
+		cmds = append(cmds, n+"||"+CMDi_TYPE_NONE+"||verb"+n+" do"+n+"||||object"+n+" now|object"+n+"/thing"+n)
+	}
+
+	return strings.Join(cmds, "\\")
+}
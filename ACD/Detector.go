@@ -0,0 +1,235 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Detector is a commands detector with its own set of commands. Various detectors can exist at the same time on the
+// same process, each with its own commands (for example one per user or per device).
+//
+// All its methods can be called concurrently. Each detection works only with its own state and with the commands set
+// that existed when it started. The commands set is never modified - updating it publishes a new one, so the updates
+// never block nor disturb the detections that are already running.
+//
+// The package-level functions (Main(), ReloadCmdsArray(), AddUpdateCmd(), ...) use a default detector, so there's no
+// need to create one if only one set of commands is needed.
+type Detector struct {
+	// cmds_set is the current commands set of the detector
+	cmds_set atomic.Pointer[cmdsSet]
+	// update_mutex serializes the updates to the commands set (the detections never lock it)
+	update_mutex sync.Mutex
+}
+
+// cmdsSet is an immutable snapshot of the commands of a Detector. Nothing on it can be modified after it's published
+// on the Detector - to change it, create a new one (updateCmdsSet() does that).
+type cmdsSet struct {
+	// cmds is the list of commands to detect
+	cmds []commandInfo
+	// cmd_types are the command types that can be used on the commands, by name (the built-in ones also by number)
+	cmd_types map[string]*CmdType
+	// main_words_trie has the main words of the commands (phrases included), with the indexes on 'cmds' of the commands
+	// that have each one, so that the detection only checks the commands triggered by the words of the sentence (built
+	// by updateCmdsSet())
+	main_words_trie *mainWordsNode
+	// tagger is the tagger of the NLP analysis (nil for ProseTagger)
+	tagger Tagger
+	// lexicon_tagger is true if 'tagger' is a LexiconTagger with the vocabulary of 'cmds' (rebuilt by updateCmdsSet())
+	lexicon_tagger bool
+	// cmds_tag_overrides are the part-of-speech tags the commands give to their words, without the ones they don't agree
+	// on (built by updateCmdsSet() - check mergeCmdsTagOverrides())
+	cmds_tag_overrides map[string]string
+	// tag_overrides are the part-of-speech tags given by the app (check Detector.SetTagOverrides())
+	tag_overrides map[string]string
+}
+
+// mainWordsNode is a node of the trie of the main words of a commands set. Each main word is a path from the root, with
+// one node per word of the main word (more than one for phrases, like "good_night").
+type mainWordsNode struct {
+	// children are the next nodes, by the next word of the phrase
+	children map[string]*mainWordsNode
+	// main_word is the main word that ends on this node ("" if none does)
+	main_word string
+	// cmds_indexes are the indexes on cmdsSet.cmds of the commands with the main word that ends on this node
+	cmds_indexes []int
+}
+
+// mainWordMatch is a main word found on a sentence by mainWordsNode.matchesAt().
+type mainWordMatch struct {
+	// main_word is the main word (with the MAIN_WORDS_PHRASE_SEP on phrases)
+	main_word string
+	// end_index is the index on the sentence of the last word of the main word
+	end_index int
+	// cmds_indexes are the indexes of the commands with the main word
+	cmds_indexes []int
+}
+
+// default_detector_GL is the detector used by the package-level functions.
+var default_detector_GL *Detector = NewDetector()
+
+/*
+NewDetector creates a new Detector with no commands loaded and only the built-in command types.
+
+-----------------------------------------------------------
+
+– Returns:
+  - the new detector
+*/
+func NewDetector() *Detector {
+	var detector *Detector = &Detector{}
+	detector.cmds_set.Store(&cmdsSet{
+		cmds:      nil,
+		cmd_types: builtin_cmd_types_map_GL,
+	})
+
+	return detector
+}
+
+/*
+getCmdsSet gets the current commands set of the detector. The detections must get it only once and use that same one
+until they finish.
+
+-----------------------------------------------------------
+
+– Returns:
+  - the current commands set
+*/
+func (detector *Detector) getCmdsSet() *cmdsSet {
+	var cmds_set *cmdsSet = detector.cmds_set.Load()
+	if cmds_set == nil {
+		// In case the Detector was not created with NewDetector().
+		return &cmdsSet{
+			cmds:      nil,
+			cmd_types: builtin_cmd_types_map_GL,
+		}
+	}
+
+	return cmds_set
+}
+
+/*
+updateCmdsSet creates a new commands set from the current one and publishes it. Only one update happens at a time.
+
+-----------------------------------------------------------
+
+– Params:
+  - update – the function that makes the changes. It gets a copy of the current set, which it can change freely - but
+    not what's inside its fields, as that's shared with the current set (for example, replace the commands on the list
+    instead of changing them, and replace the types map instead of adding to it). If it returns an error, nothing is
+    published.
+
+– Returns:
+  - the error returned by 'update'
+*/
+func (detector *Detector) updateCmdsSet(update func(cmds_set *cmdsSet) error) error {
+	detector.update_mutex.Lock()
+	defer detector.update_mutex.Unlock()
+
+	var current_set *cmdsSet = detector.getCmdsSet()
+	var new_set cmdsSet = cmdsSet{
+		cmds:           CopyOuterSLICES(current_set.cmds),
+		cmd_types:      current_set.cmd_types,
+		tagger:         current_set.tagger,
+		lexicon_tagger: current_set.lexicon_tagger,
+		tag_overrides:  current_set.tag_overrides,
+	}
+	if err := update(&new_set); err != nil {
+		return err
+	}
+	new_set.main_words_trie = newMainWordsTrie(new_set.cmds)
+	new_set.cmds_tag_overrides = mergeCmdsTagOverrides(new_set.cmds)
+	if new_set.lexicon_tagger {
+		new_set.tagger = newCmdsLexiconTagger(new_set.cmds)
+	}
+
+	detector.cmds_set.Store(&new_set)
+
+	return nil
+}
+
+/*
+newMainWordsTrie creates the trie of the main words of a commands set.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmds – the commands of the set
+
+– Returns:
+  - the root of the trie, for cmdsSet.main_words_trie
+*/
+func newMainWordsTrie(cmds []commandInfo) *mainWordsNode {
+	var root *mainWordsNode = &mainWordsNode{}
+	for i, cmd := range cmds {
+		// A command with a repeated main word is added once per repetition, as it was always checked once per each.
+		for _, main_word := range cmd.main_words {
+			var node *mainWordsNode = root
+			for _, word := range strings.Split(main_word, MAIN_WORDS_PHRASE_SEP) {
+				if node.children == nil {
+					node.children = make(map[string]*mainWordsNode)
+				}
+				var child *mainWordsNode = node.children[word]
+				if child == nil {
+					child = &mainWordsNode{}
+					node.children[word] = child
+				}
+				node = child
+			}
+			node.main_word = main_word
+			node.cmds_indexes = append(node.cmds_indexes, i)
+		}
+	}
+
+	return root
+}
+
+/*
+matchesAt finds the main words that begin on an index of a sentence.
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence – the sentence
+  - index – the index of the first word of the main words
+
+– Returns:
+  - the main words found, the longest first (the longer, the more specific - "good night" before "good")
+*/
+func (root *mainWordsNode) matchesAt(sentence []string, index int) []mainWordMatch {
+	var matches []mainWordMatch = nil
+	var node *mainWordsNode = root
+	for end_index := index; node != nil && end_index < len(sentence); end_index++ {
+		node = node.children[sentence[end_index]]
+		if node != nil && len(node.cmds_indexes) > 0 {
+			matches = append([]mainWordMatch{{
+				main_word:    node.main_word,
+				end_index:    end_index,
+				cmds_indexes: node.cmds_indexes,
+			}}, matches...)
+		}
+	}
+
+	return matches
+}
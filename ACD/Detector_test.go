@@ -0,0 +1,109 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+/*
+TestConcurrentDetection hammers a detector with detections and commands and tagger updates at the same time. Run it
+with the race detector ("go test -race ./ACD") to check the detections are really re-entrant and the updates never race
+with them.
+*/
+func TestConcurrentDetection(t *testing.T) {
+	const NUM_DETECTORS int = 4
+	const NUM_ROUNDS int = 20
+	const COMMANDS_STR string = "4||" + CMDi_TYPE_TURN_ONFF + "||||||wifi\\6||" + CMDi_TYPE_TURN_ONFF +
+		"||||||bluetooth\\11||" + CMDi_TYPE_TURN_ONFF + "||||||airplane mode\\15||" + CMDi_TYPE_NONE +
+		"||take||||picture/photo|frontal picture/photo"
+
+	// Different sentences, so different NLP states are used at the same time ("it"s, "and"s, plural pronouns and the
+	// previous command information).
+	var tests = []struct {
+		sentence      string
+		prev_cmd_info string
+	}{
+		{"turn on the wifi and the bluetooth then turn them off", "|"},
+		{"turn it off", "wifi|turn on the|"},
+		{"turn on the airplane mode and the wifi", "|"},
+		{"take a frontal picture", "|"},
+		{"turn both off", "bluetooth|turn on the|wifi+bluetooth"},
+		{"turn off the wifi and take a picture", "|"},
+	}
+
+	var detector *Detector = NewDetector()
+	if err := detector.ReloadCmdsArray(COMMANDS_STR); err != nil {
+		t.Fatal("the commands were not loaded:", err)
+	}
+	detector.UseLexiconTagger()
+	// The same tagger, but given directly - switching between both doesn't change the detections.
+	var lexicon_tagger Tagger = detector.getCmdsSet().getTagger()
+
+	// What each test outputs when nothing else is running at the same time. The concurrent detections must output
+	// exactly the same.
+	var sequential_outputs []string = nil
+	for _, test := range tests {
+		sequential_outputs = append(sequential_outputs, detector.Main(test.sentence, false, true, test.prev_cmd_info))
+	}
+
+	// The updates keep the commands used on the tests the same, so the results must not change no matter when they
+	// happen.
+	var detections_done atomic.Bool
+	var updates_wait_group sync.WaitGroup
+	updates_wait_group.Add(1)
+	go func() {
+		defer updates_wait_group.Done()
+
+		for !detections_done.Load() {
+			_ = detector.AddUpdateCmd("999||" + CMDi_TYPE_TURN_ONFF + "||||||lights")
+			detector.SetTagger(lexicon_tagger)
+			detector.RemoveCmd(999)
+			_ = detector.ReloadCmdsArray(COMMANDS_STR)
+			detector.UseLexiconTagger()
+		}
+	}()
+
+	var detections_wait_group sync.WaitGroup
+	for i := 0; i < NUM_DETECTORS; i++ {
+		detections_wait_group.Add(1)
+		go func(i int) {
+			defer detections_wait_group.Done()
+
+			for round := 0; round < NUM_ROUNDS; round++ {
+				// Each goroutine goes through the tests in a different order.
+				var test_index int = (i + round) % len(tests)
+				var output string = detector.Main(tests[test_index].sentence, false, true,
+					tests[test_index].prev_cmd_info)
+				if output != sequential_outputs[test_index] {
+					t.Errorf("%q: %q detected instead of %q", tests[test_index].sentence, output,
+						sequential_outputs[test_index])
+				}
+			}
+		}(i)
+	}
+	detections_wait_group.Wait()
+	detections_done.Store(true)
+	updates_wait_group.Wait()
+}
@@ -41,25 +41,39 @@ Main is the function to call to request a detection of commands in a given sente
     Note that repeated commands means any commands with the same ID, whether or not they have the same sub-output.
     Example: "1.00001, 1.02, 2.00001, 1.00001" --> "1.02, 2.00001, 1.00001".
   - invalidate_detec_words – same as in sentenceCmdsDetector()
+  - prev_cmd_info – the command information returned by the previous call (the part before INFO_CMDS_SEPARATOR), or
+    "" if there's none (the missing fields are empty)
 
 – Returns:
 
   - a list of the detected commands of the form
 
-	last name|last action|\\//CMD1, CMD2, CMD3, ...
+	last name|last action|last names\\//CMD1, CMD2, CMD3, ...
 
     The separators are INFO_CMDS_SEPARATOR, CMDS_SEPARATOR and PREV_CMD_INFO_SEPARATOR.
   - If the function detected no commands, an empty string will be after INFO_CMDS_SEPARATOR. The last name is the last
     name detected in the sentence (can be more than one, like "airplane mode"), and the same goes for the last action
-    ("turn on the" wifi, for example).
+    ("turn on the" wifi, for example). The last names are the names of the last noun phrases coordinated by "and"s
+    ("wifi+airplane mode" on "the wifi and the airplane mode", separated by LAST_NAMES_SEPARATOR), or nothing if there
+    are less than 2 - for a "them" on the next sentence.
   - If any error occurred, a string beginning with ERR_CMD_DETECT, followed by a Go error.
+
+Outside Gomobile, prefer Detect(), which returns the same information but already decoded.
 */
 func Main(sentence_str string, remove_repet_cmds bool, invalidate_detec_words bool, prev_cmd_info string) string {
+	return default_detector_GL.Main(sentence_str, remove_repet_cmds, invalidate_detec_words, prev_cmd_info)
+}
+
+/*
+Main is the same as the package-level Main() but uses this detector's commands.
+*/
+func (detector *Detector) Main(sentence_str string, remove_repet_cmds bool, invalidate_detec_words bool,
+	prev_cmd_info string) string {
 	var ret_var string = ""
 
 	Tcef.Tcef{
 		Try: func() {
-			ret_var = MainInternal(sentence_str, remove_repet_cmds, invalidate_detec_words, prev_cmd_info)
+			ret_var = detector.MainInternal(sentence_str, remove_repet_cmds, invalidate_detec_words, prev_cmd_info)
 		},
 		Catch: func(e Tcef.Exception) {
 			ret_var = ERR_CMD_DETECT + fmt.Sprint(e)
@@ -73,6 +87,44 @@ const INFO_CMDS_SEPARATOR string = "\\\\//"
 const PREV_CMD_INFO_SEPARATOR string = "|"
 const CMDS_SEPARATOR string = ", "
 
+/*
+Detect is the same as Main(), but returns the detected commands in a structured way instead of encoded in a string.
+
+It's not usable through Gomobile (slices of structs are not supported there), so on Android use Main() instead.
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence_str – same as in Main()
+  - options – the options for the detection (check DetectOptions)
+
+– Returns:
+  - the detection result
+  - an error if anything went wrong while detecting the commands, nil otherwise
+*/
+func Detect(sentence_str string, options DetectOptions) (DetectionResult, error) {
+	return default_detector_GL.Detect(sentence_str, options)
+}
+
+/*
+Detect is the same as the package-level Detect() but uses this detector's commands.
+*/
+func (detector *Detector) Detect(sentence_str string, options DetectOptions) (DetectionResult, error) {
+	var result DetectionResult
+	var err error = nil
+
+	Tcef.Tcef{
+		Try: func() {
+			result = detector.detectInternal(sentence_str, options)
+		},
+		Catch: func(e Tcef.Exception) {
+			err = fmt.Errorf("%s%v", ERR_CMD_DETECT, e)
+		},
+	}.Do()
+
+	return result, err
+}
+
 /*
 MainInternal is the actual function that will do what's written on Main() - continue reading there.
 
@@ -82,65 +134,168 @@ instead (no protection here), so always call the other one in production code.
 Note: if you find this function exported, know it's just for testing from the main package. Do NOT use it in production.
 */
 func MainInternal(sentence_str string, remove_repet_cmds bool, invalidate_detec_words bool, prev_cmd_info string) string {
+	return default_detector_GL.MainInternal(sentence_str, remove_repet_cmds, invalidate_detec_words, prev_cmd_info)
+}
+
+/*
+MainInternal is the same as the package-level MainInternal() but uses this detector's commands.
+*/
+func (detector *Detector) MainInternal(sentence_str string, remove_repet_cmds bool, invalidate_detec_words bool,
+	prev_cmd_info string) string {
+	if strings.TrimSpace(sentence_str) == "" {
+		// Keep the old behavior of returning nothing at all for empty sentences.
+
+		return ""
+	}
+
+	var prev_cmd_info_list []string = strings.Split(prev_cmd_info, PREV_CMD_INFO_SEPARATOR)
+	for len(prev_cmd_info_list) < 3 {
+		// Missing fields are empty, as a missing context on Detect().
+		prev_cmd_info_list = append(prev_cmd_info_list, "")
+	}
+	var options DetectOptions = DetectOptions{
+		Remove_repet_cmds:      remove_repet_cmds,
+		Invalidate_detec_words: invalidate_detec_words,
+		Prev_cmd_context: CmdContext{
+			Last_name:   prev_cmd_info_list[0],
+			Last_action: prev_cmd_info_list[1],
+		},
+	}
+	if prev_cmd_info_list[2] != "" {
+		options.Prev_cmd_context.Last_names = strings.Split(prev_cmd_info_list[2], LAST_NAMES_SEPARATOR)
+	}
+	var result DetectionResult = detector.detectInternal(sentence_str, options)
+
+	return encodeDetectionResult(result)
+}
+
+/*
+detectInternal is the actual function that will do what's written on Detect(), but panicking if anything goes wrong.
+*/
+func (detector *Detector) detectInternal(sentence_str string, options DetectOptions) DetectionResult {
+	var result DetectionResult = DetectionResult{}
+
 	if strings.TrimSpace(sentence_str) == "" {
 		// If the string is empty on visible characters (space counts as invisible here...), return now, because the
 		// code ahead may not work with strings like that (and some of it does not - panic --> reason I'm returning
 		// here).
 
-		return ""
+		return result
+	}
+
+	var trace *DetectionTrace = nil
+	if options.Trace {
+		trace = &DetectionTrace{}
+		result.Trace = trace
 	}
 
+	// Each word of the sentence is followed back to where it came from on the original sentence through all the changes
+	// below (check alignWords()).
+	var original_sentence_str string = sentence_str
+	var words_origins []wordOrigin = sentenceWordsOrigins(sentence_str)
+	var prev_sentence []string = strings.Split(sentence_str, " ")
+
 	sentence_str = sentenceCorrection(sentence_str, nil, true)
 
 	var sentence []string = strings.Split(sentence_str, " ")
+	words_origins = alignWords(prev_sentence, words_origins, sentence)
+	if trace != nil {
+		trace.Corrected_sentence = append([]string(nil), sentence...)
+	}
 
 	// Prepare the sentence for the NLP analysis
+	prev_sentence = sentence
 	sentence_str = sentenceNLPPreparation(sentence_str, &sentence, true)
+	words_origins = alignWords(prev_sentence, words_origins, sentence)
+	var cmds_set *cmdsSet = detector.getCmdsSet()
+
 	// Analyze the sentence with NLP help and, for example, replace all the "it"s on the sentence with their meaning
-	var nlp_meanings []string = nlpAnalyzer(&sentence, sentence_str, strings.Split(prev_cmd_info, "|"))
+	nlp_meanings, words_origins := nlpAnalyzer(&sentence, sentence_str, cmds_set,
+		[]string{options.Prev_cmd_context.Last_name, options.Prev_cmd_context.Last_action,
+			strings.Join(options.Prev_cmd_context.Last_names, LAST_NAMES_SEPARATOR)}, words_origins, trace)
 	sentence_str = strings.Join(sentence, " ") // Rebuild the sentence with the changes made by the NLP analyzer
+	prev_sentence = sentence
 	// "Unprepare" what was prepared on the sentence for the NLP analysis
 	/*sentence_str = */
 	sentenceNLPPreparation(sentence_str, &sentence, false) //--> uncomment the beginning if sentence_str is needed
+	words_origins = alignWords(prev_sentence, words_origins, sentence)
 
 	sentenceCorrection("", &sentence, false)
 
 	//log.Println(sentence)
+	if trace != nil {
+		// Copied because the detection may invalidate the words used.
+		trace.Detection_sentence = append([]string(nil), sentence...)
+	}
 
 	// Get all the commands present on the sentence.
-	var sentence_cmds []float32 = sentenceCmdsDetector(sentence, invalidate_detec_words)
+	var near_misses *[]NearMiss = nil
+	if options.Report_near_misses {
+		near_misses = &result.Near_misses
+	}
+	var sentence_cmds []detectedCmd = nil
+	if options.Optimal_segmentation {
+		sentence_cmds = sentenceCmdsDetectorOptimal(cmds_set, sentence, words_origins,
+			options.Report_ambiguities, near_misses, trace)
+	} else {
+		sentence_cmds = sentenceCmdsDetector(cmds_set, sentence, words_origins,
+			options.Invalidate_detec_words, options.Report_ambiguities, near_misses, trace)
+	}
 
-	// Filter the sentence of special commands (like "don't"/"do not") and do the necessary for each special command.
-	taskFilter(&sentence_cmds)
+	result.Unconsumed = unconsumedSegments(original_sentence_str, words_origins, sentence_cmds)
 
-	var ret_var string = ""
+	// Filter the sentence of special commands (like "don't"/"do not") and do the necessary for each special command.
+	taskFilter(&sentence_cmds, trace)
 
-	var cmd_info string = ""
-	for _, meaning := range nlp_meanings {
-		cmd_info += meaning + PREV_CMD_INFO_SEPARATOR
+	result.Cmd_context = CmdContext{
+		Last_name:   nlp_meanings[0],
+		Last_action: nlp_meanings[1],
 	}
-	ret_var += cmd_info + INFO_CMDS_SEPARATOR
-
-	var detected_commands string = ""
-	for _, command := range sentence_cmds {
-		detected_commands += fmt.Sprint(command) + CMDS_SEPARATOR
+	if nlp_meanings[2] != "" {
+		result.Cmd_context.Last_names = strings.Split(nlp_meanings[2], LAST_NAMES_SEPARATOR)
 	}
-	if "" != detected_commands {
-		detected_commands = detected_commands[:len(detected_commands)-len(CMDS_SEPARATOR)]
+	for _, command := range sentence_cmds {
+		var detection Detection = newDetection(command)
+		detection.Needs_confirmation = detection.Score < options.Confirmation_threshold
+		result.Detections = append(result.Detections, detection)
 	}
 
-	//log.Println("::::::::::::::::::::::::::::::::::")
-	//log.Println(ret_var)
-
 	// Remove consecutively repeated commands
 	// Let's see if the verification function can handle it without this...
 	// EDIT: it could very well (improved a lot since then), but it's needed again, at least sometimes. So I'm putting
 	// it optional.
-	if remove_repet_cmds {
-		detected_commands = removeRepeatedCmds(detected_commands)
+	if options.Remove_repet_cmds {
+		removeRepeatedCmds(&result.Detections)
 	}
 
-	ret_var += detected_commands
+	return result
+}
+
+/*
+encodeDetectionResult encodes a detection result in the string form returned by Main().
+
+-----------------------------------------------------------
+
+– Params:
+  - result – the detection result
+
+– Returns:
+  - the string with the result, as explained on Main()
+*/
+func encodeDetectionResult(result DetectionResult) string {
+	var ret_var string = result.Cmd_context.Last_name + PREV_CMD_INFO_SEPARATOR + result.Cmd_context.Last_action +
+		PREV_CMD_INFO_SEPARATOR + strings.Join(result.Cmd_context.Last_names, LAST_NAMES_SEPARATOR) +
+		INFO_CMDS_SEPARATOR
+
+	var detected_commands []string = nil
+	for _, detection := range result.Detections {
+		detected_commands = append(detected_commands, detection.String())
+	}
+
+	//log.Println("::::::::::::::::::::::::::::::::::")
+	//log.Println(ret_var)
+
+	ret_var += strings.Join(detected_commands, CMDS_SEPARATOR)
 
 	//log.Println(ret_var)
 	//log.Println("::::::::::::::::::::::::::::::::::")
@@ -173,73 +328,102 @@ another function call.
 (As a curiosity, the overall Main() function can now know what to do in the example above, without needing to execute
 this function at all!!! A thanks to this might be due to the new parameter on the wordsVerificationFunction() that
 ignores possibly repeated commands!)
-*/
-func removeRepeatedCmds(detected_cmds_str_param string) string {
-	var detected_cmds_list []string = strings.Split(detected_cmds_str_param, CMDS_SEPARATOR)
-
-	const MARK_TERMINATION_STR string = "3234_TERM"
-
-	var detected_cmds_list_len_1 int = len(detected_cmds_list) - 1
-	for i, j := range detected_cmds_list {
-		if i != detected_cmds_list_len_1 {
-			var next_j string = detected_cmds_list[i+1]
-			var next_j_index int = strings.Index(next_j, ".")
-			var j_index int = strings.Index(j, ".")
-			//log.Println("-------")
-			//log.Println(prev_j)
-			//log.Println(prev_j[:prev_j_index)
-			//log.Println(j)
-			//log.Println(j[:j_index)
-			if (next_j_index != -1) && (j_index != -1) && (j[:j_index] == next_j[:next_j_index]) {
-				detected_cmds_list[i] = MARK_TERMINATION_STR
-			}
-		}
-	}
 
-	var detected_cmds_str string = ""
-	for _, command := range detected_cmds_list {
-		if command != MARK_TERMINATION_STR {
-			detected_cmds_str += command + CMDS_SEPARATOR
-		}
-	}
+Warnings are never considered repeated commands.
 
-	if strings.HasSuffix(detected_cmds_str, CMDS_SEPARATOR) {
-		detected_cmds_str = detected_cmds_str[:len(detected_cmds_str)-len(CMDS_SEPARATOR)]
-	}
+-----------------------------------------------------------
 
-	return detected_cmds_str
+– Params:
+  - detections – a pointer to the header of the slice with the detections
+
+– Returns:
+  - nothing
+*/
+func removeRepeatedCmds(detections *[]Detection) {
+	for i := 0; i < len(*detections)-1; {
+		// Don't forget (again) --> the length must checked every time on the loop because it is changed on it
+		var curr_detection Detection = (*detections)[i]
+		var next_detection Detection = (*detections)[i+1]
+		if curr_detection.Warning == WARNING_NONE && next_detection.Warning == WARNING_NONE &&
+			curr_detection.Cmd_id == next_detection.Cmd_id {
+			DelElemSLICES(detections, i)
+		} else {
+			i++
+		}
+	}
 }
 
 const ANY_MAIN_WORD string = ";4;"
 
 // ATTENTION - none of these constants below can collide with the WARN_-started constants on CmdsInfo!!!
-// const SPEC_CMD_DONT_INSTEAD float32 = -1.1
-// const SPEC_CMD_STOP float32 = -2
-// const SPEC_CMD_FORGET float32 = -3
-const _SPEC_CMD_DONT float32 = -1
-const _SPEC_CMD_NEVER_MIND float32 = -2
+// const SPEC_CMD_DONT_INSTEAD int = -1.1
+// const SPEC_CMD_STOP int = -2
+// const SPEC_CMD_FORGET int = -3
+const _SPEC_CMD_DONT int = -1
+const _SPEC_CMD_NEVER_MIND int = -2
+
+// detectedCmd is a command detected by sentenceCmdsDetector(), represented by the exact pair of the command ID and the
+// index of the detected condition (the variation), plus the score of the detection. Special commands and warnings have
+// their (negative) number as the 'cmd_id' and -1 as the 'sub_cmd_index'.
+type detectedCmd struct {
+	cmd_id        int
+	sub_cmd_index int
+	// score is the confidence on the detection, from 0 to 1 (check detectionScore()) - always 1 for special commands and
+	// warnings
+	score float64
+	// main_word_span is the span on the original sentence of the main word (or of the special command or warning)
+	main_word_span TextSpan
+	// words_spans are the spans on the original sentence of the words found for the condition (check
+	// matchedWordsSpans())
+	words_spans []TextSpan
+	// candidates are the commands and conditions tied for the detection (check tiedCandidates())
+	candidates []DetectionCandidate
+}
+
+/*
+isSameCmd checks if 2 detected commands are the same command and condition, whatever their scores.
+
+-----------------------------------------------------------
+
+– Params:
+  - other – the other detected command
+
+– Returns:
+  - true if they're the same, false otherwise
+*/
+func (command detectedCmd) isSameCmd(other detectedCmd) bool {
+	return command.cmd_id == other.cmd_id && command.sub_cmd_index == other.sub_cmd_index
+}
 
 const _INVALIDATE_WORD string = ";5;"
 
 /*
-sentenceCmdsDetector detects which of the cmds_GL commands are present in a sentence of words.
+sentenceCmdsDetector detects which of the given commands are present in a sentence of words.
 
 -----------------------------------------------------------
 
 – Params:
+  - cmds_set – the commands set with the commands to detect
   - sentence – a 1D slice of words on which the verification will be executed (basically it's sentence_str required by
     Main() split by spaces in a 1D slice).
+  - words_origins – the origins of the words of the 'sentence' on the original sentence (nil if they're not known) -
+    to know the spans of the detections and which words were put there by the NLP analysis
   - invalidate_detec_words – true to invalidate words used on detections so that they're not used on further detections
     (useful to prevent wrong detections), false otherwise. Example of a problematic sentence: "fast reboot the phone", with
     "fast" and "reboot" being both command main words - 2 command detections will be triggered and phone (fast reboot and
     reboot normally) --> with this set to true, not anymore, because each word used on a successful detection will be
     replaced by _INVALIDATE_WORD and hence will not be used again.
+  - report_ambiguities – true to get the commands and conditions tied for each detection (check tiedCandidates()),
+    false otherwise
+  - near_misses – where to add the commands triggered by main words from which no command was detected (check
+    nearMiss()), or nil to not look for them
+  - trace – the trace to record the main words and the conditions tried on, or nil to not record them
 
 – Returns:
 
 – a slice on which each index is a command found in the 'sentence' in the order provided by the 'sentence'. The command
-is a float in which the integer part is the index of the command on cmds_GL and the decimal part is the index+1 of the
-detected condition of the command, with each condition incrementing by 0.00001. For example, for
+is a pair in which the first element is the ID of the command on 'cmds_set' and the second is the index of the detected
+condition of the command. For example, for
 
 	{ // 14
 		{{{-1}, {"device", "phone"}}, {{-1}, {"safe"}}, {-1: {"mode"}}},
@@ -247,94 +431,163 @@ detected condition of the command, with each condition incrementing by 0.00001.
 		{{{-1}, {"device", "phone"}}},
 	},
 
-and the sentence "reboot the device to recovery", the output will be 14.02 (command ID 14, 2nd condition).
+and the sentence "reboot the device to recovery", the output will be {14, 1} (command ID 14, 2nd condition), which
+Main() encodes as 14.00002.
 */
-func sentenceCmdsDetector(sentence []string, invalidate_detec_words bool) []float32 {
-	var detected_cmds []float32 = nil
-
-	for sentence_counter, sentence_word := range sentence {
-
-		if sentence_word == "don't" {
-			detected_cmds = append(detected_cmds, _SPEC_CMD_DONT)
-		} else if sentence_word == "never" && sentence[sentence_counter+1] == "mind" {
-			detected_cmds = append(detected_cmds, _SPEC_CMD_NEVER_MIND)
-		} else if sentence_word == WHATS_IT {
-			float, _ := strconv.ParseFloat(WARN_WHATS_IT, 32)
-			detected_cmds = append(detected_cmds, float32(float))
-		} else if sentence_word == WHATS_AND {
-			float, _ := strconv.ParseFloat(WARN_WHATS_AND, 32)
-			detected_cmds = append(detected_cmds, float32(float))
+func sentenceCmdsDetector(cmds_set *cmdsSet, sentence []string, words_origins []wordOrigin,
+	invalidate_detec_words bool, report_ambiguities bool, near_misses *[]NearMiss,
+	trace *DetectionTrace) []detectedCmd {
+	var detected_cmds []detectedCmd = nil
+	var cmds []commandInfo = cmds_set.cmds
+
+	for sentence_counter := range sentence {
+
+		if special_cmd, num_words, ok := specialCmdAt(sentence, sentence_counter); ok {
+			special_cmd.main_word_span = wordsSpan(words_origins, sentence_counter, sentence_counter+num_words-1)
+			detected_cmds = append(detected_cmds, special_cmd)
 		} else {
-			for i := range cmds_GL {
-				// Uncomment for testing
-				//if cmds_GL[i].cmd_id != 14 {
-				//	continue
-				//}
-				for _, main_word := range cmds_GL[i].main_words {
-					if main_word == sentence_word {
-
-						//log.Println("==============")
-						//log.Println(sentence_word)
-						//log.Println(i)
-
-						var results_WordsVerificationDADi [][][]interface{} = wordsVerificationFunction(sentence,
-							sentence_counter, cmds_GL[i])
-
-						//log.Println("-----------")
-						//log.Println(results_WordsVerificationDADi)
+			// Only the commands with main words beginning on this word are checked (once per time they have each).
+			var main_word_matches []mainWordMatch = cmds_set.main_words_trie.matchesAt(sentence, sentence_counter)
+			trace.addMainWords(sentence_counter, main_word_matches, cmds)
+			// The near misses are only of the main words from which nothing was detected.
+			var main_word_detected bool = false
+			var main_word_near_misses []NearMiss = nil
+			for _, main_word_match := range main_word_matches {
+				var candidates []ambiguityCandidate = nil
+				if report_ambiguities {
+					candidates = mainWordCandidates(cmds_set, sentence, words_origins, sentence_counter,
+						main_word_match)
+				}
+
+				for _, i := range main_word_match.cmds_indexes {
+					// Uncomment for testing
+					//if cmds[i].cmd_id != 14 {
+					//	continue
+					//}
+
+					//log.Println("==============")
+					//log.Println(main_word_match.main_word)
+					//log.Println(i)
+
+					var results_WordsVerificationDADi []conditionMatch = wordsVerificationFunction(sentence,
+						sentence_counter, main_word_match.end_index, cmds[i])
 
-						if len(results_WordsVerificationDADi) > 0 {
-							//log.Println("_____________")
-							//log.Println(cmds_GL[i].cmd_id)
-							//log.Println(results_WordsVerificationDADi)
-							var final_cond int = checkMainWordsRetConds(results_WordsVerificationDADi, sentence_word, i)
-							if final_cond != -1 {
-								var detected_command float32 = float32(final_cond+1)/MAX_SUB_CMDS + float32(cmds_GL[i].cmd_id)
-								detected_cmds = append(detected_cmds, detected_command)
-								// results_WordsVerificationDADi + 1 because 0.00 must not happen
-								// / 100 to go from 0+1 = 1 to 0.00001
-								// + cmd_index because what returns from the function is the return command ID for that
-								// specific command - not a global one --> this makes it global (always different)
-
-								// Uncomment for testing
-								//if detected_command == 1.00001 {
-								//	log.Println("==============")
-								//	log.Println(sentence_word)
-								//	log.Println(i)
-								//	log.Println("-----------")
-								//	log.Println(results_WordsVerificationDADi)
-								//	log.Println("_____________")
-								//	log.Println(cmds_GL[i])
-								//	log.Println(sentence)
-								//}
-
-								if invalidate_detec_words {
-									sentence[sentence_counter] = _INVALIDATE_WORD
-									for _, j := range results_WordsVerificationDADi[final_cond] {
-										var index int = j[1].(int)
-										if index >= 0 {
-											sentence[index] = _INVALIDATE_WORD
-										}
+					//log.Println("-----------")
+					//log.Println(results_WordsVerificationDADi)
+
+					if len(results_WordsVerificationDADi) > 0 {
+						//log.Println("_____________")
+						//log.Println(cmds[i].cmd_id)
+						//log.Println(results_WordsVerificationDADi)
+						var final_cond int = checkMainWordsRetConds(results_WordsVerificationDADi,
+							main_word_match.main_word, cmds[i])
+						trace.addVerification(cmds[i], main_word_match.main_word, sentence_counter,
+							results_WordsVerificationDADi, final_cond)
+						if final_cond == -1 && near_misses != nil {
+							if near_miss, ok := nearMiss(cmds[i], results_WordsVerificationDADi,
+								main_word_match.main_word, wordsSpan(words_origins, sentence_counter,
+									main_word_match.end_index), words_origins); ok {
+								main_word_near_misses = append(main_word_near_misses, near_miss)
+							}
+						}
+						if final_cond != -1 {
+							// The conditions were reordered when loaded, so give back the variant of the definition.
+							var detected_command detectedCmd = detectedCmd{
+								cmd_id:        cmds[i].cmd_id,
+								sub_cmd_index: cmds[i].variants[final_cond],
+								score: detectionScore(cmds[i], final_cond, results_WordsVerificationDADi[final_cond],
+									main_word_match, sentence_counter, words_origins),
+								main_word_span: wordsSpan(words_origins, sentence_counter, main_word_match.end_index),
+								words_spans: matchedWordsSpans(words_origins,
+									results_WordsVerificationDADi[final_cond]),
+							}
+							detected_command.candidates = tiedCandidates(candidates, detected_command,
+								results_WordsVerificationDADi[final_cond].wordsFound())
+							detected_cmds = append(detected_cmds, detected_command)
+							main_word_detected = true
+							// The command ID goes with the condition index because what returns from the function
+							// is the return condition for that specific command - not a global one --> this makes
+							// it global (always different)
+
+							// Uncomment for testing
+							//if detected_command == (detectedCmd{1, 0}) {
+							//	log.Println("==============")
+							//	log.Println(main_word_match.main_word)
+							//	log.Println(i)
+							//	log.Println("-----------")
+							//	log.Println(results_WordsVerificationDADi)
+							//	log.Println("_____________")
+							//	log.Println(cmds[i])
+							//	log.Println(sentence)
+							//}
+
+							if invalidate_detec_words {
+								// The whole main word (all the words of a phrase) and the words found.
+								for index := sentence_counter; index <= main_word_match.end_index; index++ {
+									sentence[index] = _INVALIDATE_WORD
+								}
+								for _, word_match := range results_WordsVerificationDADi[final_cond].words_matches {
+									if word_match.index >= 0 {
+										sentence[word_match.index] = _INVALIDATE_WORD
 									}
 								}
-
-								// Uncomment for testing
-								//if detected_command == 1.00001 {
-								//	log.Println("-----------")
-								//	//log.Println(results_WordsVerificationDADi[final_cond])
-								//	log.Println(sentence)
-								//}
 							}
+
+							// Uncomment for testing
+							//if detected_command == (detectedCmd{1, 0}) {
+							//	log.Println("-----------")
+							//	//log.Println(results_WordsVerificationDADi[final_cond])
+							//	log.Println(sentence)
+							//}
 						}
 					}
 				}
 			}
+			if !main_word_detected && near_misses != nil {
+				*near_misses = append(*near_misses, main_word_near_misses...)
+			}
 		}
 	}
 
 	return detected_cmds
 }
 
+/*
+specialCmdAt checks if there's a special command or a warning on an index of a sentence ("don't", "never mind", or the
+WHATS_IT and WHATS_AND marks of the NLP analysis).
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence – the sentence
+  - index – the index
+
+– Returns:
+  - the special command or warning
+  - the number of words of the sentence it takes
+  - true if there's one on the index, false otherwise
+*/
+func specialCmdAt(sentence []string, index int) (detectedCmd, int, bool) {
+	switch sentence[index] {
+		case "don't":
+			return detectedCmd{cmd_id: _SPEC_CMD_DONT, sub_cmd_index: -1, score: 1}, 1, true
+		case "never":
+			if index+1 < len(sentence) && sentence[index+1] == "mind" {
+				return detectedCmd{cmd_id: _SPEC_CMD_NEVER_MIND, sub_cmd_index: -1, score: 1}, 2, true
+			}
+		case WHATS_IT:
+			warn_id, _ := strconv.Atoi(WARN_WHATS_IT)
+
+			return detectedCmd{cmd_id: warn_id, sub_cmd_index: -1, score: 1}, 1, true
+		case WHATS_AND:
+			warn_id, _ := strconv.Atoi(WARN_WHATS_AND)
+
+			return detectedCmd{cmd_id: warn_id, sub_cmd_index: -1, score: 1}, 1, true
+	}
+
+	return detectedCmd{}, 0, false
+}
+
 /*
 taskFilter filters a sentence of commands depending on special commands present on it.
 
@@ -345,15 +598,16 @@ For example, "turn on the lights and play some music. no, don't turn on the ligh
 
 – Params:
   - sentence_cmds – same as in sentenceCmdsDetector()
+  - trace – the trace to record the deleted commands on, or nil to not record them
 
 – Returns:
   - nothing
 */
-func taskFilter(sentence_cmds *[]float32) {
+func taskFilter(sentence_cmds *[]detectedCmd, trace *DetectionTrace) {
 	// For testing
 	//*sentence_filtered = [][]string{{"test"}, {"test"}, {"test 234 lkj"}, {"test"}, {"test"}, {"test"}, {"test"},
 	//	{"test"}, {"test"}, {"test"}, {"test"}, {"test"}, {"test"}, {"test"}, {"test"}, }
-	//*sentence_cmds = []float32{24, -1, 26, 25, -1, -1, -1, 25, 24}
+	//*sentence_cmds = []detectedCmd{{24, 0}, {-1, -1}, {26, 0}, {25, 0}, {-1, -1}, {-1, -1}, {-1, -1}, {25, 0}, {24, 0}}
 
 	//log.Println("==============================================")
 	//log.Println("*sentence_cmds -->", *sentence_cmds)
@@ -361,31 +615,38 @@ func taskFilter(sentence_cmds *[]float32) {
 	// RESTRICTED VALUE ON THE sentence_cmds SLICE - Used to mark elements for deletion on the slice. This way, they're
 	// deleted only in the end and on the main loop it doesn't get confusing about which elements have been deleted
 	// already.
-	const MARK_TERMINATION_FLOAT32 float32 = 0
+	var MARK_TERMINATION detectedCmd = detectedCmd{}
+	// markTermination marks an element for deletion and records why on the trace (only the first time it's marked).
+	var markTermination = func(index int, reason string) {
+		if !(*sentence_cmds)[index].isSameCmd(MARK_TERMINATION) {
+			trace.addFiltered(index, (*sentence_cmds)[index], reason)
+		}
+		(*sentence_cmds)[index] = MARK_TERMINATION
+	}
 
 	for counter, number := range *sentence_cmds {
-		if number == _SPEC_CMD_DONT || number == _SPEC_CMD_NEVER_MIND {
+		if number.cmd_id == _SPEC_CMD_DONT || number.cmd_id == _SPEC_CMD_NEVER_MIND {
 			//log.Println("0 -", *sentence_cmds)
 
 			// Delete the "don't" or "never mind"
-			(*sentence_cmds)[counter] = MARK_TERMINATION_FLOAT32
+			markTermination(counter, "special command, already applied")
 
 			//log.Println("1 -", *sentence_cmds)
-			if number == _SPEC_CMD_DONT {
+			if number.cmd_id == _SPEC_CMD_DONT {
 				var delete_number_before bool = false
 
 				if counter != len(*sentence_cmds)-1 {
 					// If the next index is within the maximum index (which means, if the next number exists)...
 
-					var next_number float32 = (*sentence_cmds)[counter+1]
-					if next_number > 0 { // Means if it's a normal command. If it is, assume the below case.
+					var next_number detectedCmd = (*sentence_cmds)[counter+1]
+					if next_number.cmd_id > 0 { // Means if it's a normal command. If it is, assume the below case.
 						// Case: "do [1] and do [2]. no don't do [1]" - delete this, don't, and this. Also, if by any reason
 						// there are more copies of [1], delete them also - if they're before the next element only.
 
 						var number_mentioned bool = false
 						var pos_next_number []int = nil
 						for counter1, number1 := range *sentence_cmds {
-							if number1 == next_number {
+							if number1.isSameCmd(next_number) {
 								pos_next_number = append(pos_next_number, counter1)
 								number_mentioned = true
 							}
@@ -396,12 +657,13 @@ func taskFilter(sentence_cmds *[]float32) {
 						}
 						if number_mentioned {
 							// If the number was mentioned before (like [24, 25, 24, -1, 24]), delete all copies and the -1.
-							(*sentence_cmds)[counter+1] = MARK_TERMINATION_FLOAT32
+							markTermination(counter+1, fmt.Sprintf("cancelled by the \"don't\" on [%d]", counter))
 
 							//log.Println("2 -", *sentence_cmds)
 
 							for _, index_element := range pos_next_number {
-								(*sentence_cmds)[index_element] = MARK_TERMINATION_FLOAT32
+								markTermination(index_element, fmt.Sprintf("copy of the command cancelled by the "+
+									"\"don't\" on [%d]", counter))
 							}
 							//log.Println("3 -", *sentence_cmds)
 						} else {
@@ -426,19 +688,19 @@ func taskFilter(sentence_cmds *[]float32) {
 
 				if delete_number_before {
 					// Do it only if there's a normal command before. If it's for example WARN_WHATS_IT, don't delete it.
-					if counter-1 >= 0 && (*sentence_cmds)[counter-1] > 0 {
-						(*sentence_cmds)[counter-1] = MARK_TERMINATION_FLOAT32
+					if counter-1 >= 0 && (*sentence_cmds)[counter-1].cmd_id > 0 {
+						markTermination(counter-1, fmt.Sprintf("cancelled by the \"don't\" on [%d]", counter))
 						//log.Println("4 -", *sentence_cmds)
 					}
 				}
-			} else if number == _SPEC_CMD_NEVER_MIND {
+			} else if number.cmd_id == _SPEC_CMD_NEVER_MIND {
 				// Delete the "never mind"
-				(*sentence_cmds)[counter] = MARK_TERMINATION_FLOAT32
+				markTermination(counter, "special command, already applied")
 
 				// Delete all the numbers before the "never mind"
 				for counter1 := counter - 1; counter1 >= 0; counter1-- {
-					if (*sentence_cmds)[counter1] > 0 {
-						(*sentence_cmds)[counter1] = MARK_TERMINATION_FLOAT32
+					if (*sentence_cmds)[counter1].cmd_id > 0 {
+						markTermination(counter1, fmt.Sprintf("cancelled by the \"never mind\" on [%d]", counter))
 					} else {
 						break
 					}
@@ -452,7 +714,7 @@ func taskFilter(sentence_cmds *[]float32) {
 	// Delete all elements marked for deletion
 	for counter := 0; counter < len(*sentence_cmds); {
 		// Don't forget (again) --> the length must checked every time on the loop because it is changed on it
-		if (*sentence_cmds)[counter] == MARK_TERMINATION_FLOAT32 {
+		if (*sentence_cmds)[counter].isSameCmd(MARK_TERMINATION) {
 			DelElemSLICES(sentence_cmds, counter)
 		} else {
 			counter++
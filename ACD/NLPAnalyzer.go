@@ -23,82 +23,75 @@ package ACD
 
 import (
 	"strings"
-
-	"github.com/jdkato/prose/v2"
 )
 
 //////////////////////////
-// Global variables (the "nlp_" prefix is because the variables are global for the *package*)
+// State of each analysis (it was made of package global variables before, but then 2 analyses couldn't happen at the
+// same time - now each call to nlpAnalyzer() has its own)
+
+type nlpState struct {
+	sentence_counter int
 
-var nlp_sentence_counter int
-var nlp_token_counter int
+	// words_tags are the part-of-speech tags of the words of the sentence, updated as words are replaced and deleted
+	// (so that each tag is always the one of the word on the same index - check alignTokensTags())
+	words_tags []string
+	// words_origins are the origins of the words of the sentence, updated as words are replaced and deleted
+	words_origins []wordOrigin
+	// trace is the trace to record the tags and the substitutions on, or nil to not record them
+	trace *DetectionTrace
+
+	// For replaceIts()
 
-// For replaceIts()
+	last_was_an_it                  bool
+	non_name_passed_since_last_name bool
+	last_name_found                 []string
+	last_it                         string
+	prev_sentence_it                string
 
-var nlp_last_was_an_it bool
-var nlp_non_name_passed_since_last_name bool
-var nlp_last_name_found []string
-var nlp_last_it string
-var prev_sentence_it string
+	// For replacePlurals() (kept by replaceIts())
 
-// For replaceAnds()
+	// names_group are the names of the last noun phrases coordinated by "and"s or "or"s ("the wifi and the airplane
+	// mode" --> {{"wifi"}, {"airplane", "mode"}})
+	names_group                 [][]string
+	and_passed_since_last_name  bool
+	verb_passed_since_last_name bool
+	// last_coordinated_group is the last names_group with 2 or more names (the one the plural pronouns refer to - a
+	// single name after it doesn't replace it)
+	last_coordinated_group [][]string
 
-var nlp_last_was_an_and bool
-var nlp_non_allowed_tag_passed_since_last_allowed bool
-var nlp_verbs_passed int
-var nlp_second_last_to_last_non_allowed_tag []string
-var nlp_last_and string
-var prev_sentence_and string
+	// For replaceAnds()
+
+	last_was_an_and                           bool
+	non_allowed_tag_passed_since_last_allowed bool
+	verbs_passed                              int
+	second_last_to_last_non_allowed_tag       []string
+	// last_action_tags are the tags of the words of second_last_to_last_non_allowed_tag (none if it came from the
+	// previous sentence)
+	last_action_tags                          []string
+	last_and                                  string
+	prev_sentence_and                         string
+}
 
 // This is a list of words and tags to always be applied to the NLP tagging output. Sometimes it sees "mode" as a verb,
 // for example. That's wtf, I think. In any case, for the purposes of the assistant at the moment, "mode" is always a
 // name for the required commands (even though I think "mode" is always a name - like "do" is always a verb and so on).
 // "turn" may be a name too ("number of turns" or "it's my turn"), but again, for the purposes of the assistant, "turn"
 // is always a verb, so assume all "turn" instances on the 'sentence' are verbs.
-// This applies for Prose in its current version (writing this on 2021-11-20).
+// This applies for Prose in its current version (writing this on 2021-11-20) - and the tags are applied over the ones
+// of any Tagger, as the analysis counts on them.
 // Note: I took the tags below from an online P.O.S. tagger (https://parts-of-speech.info) in sentences that would make
 // it obvious what each word is (name, verb, adjective...).
-// Note 2: I've just added all the verbs present cmds_types_keywords array, so that they are always recognized as verbs.
+// Note 2: the words of the commands ("turn", "record", "mode", "bluetooth"...) are not here anymore - each command gives
+// the tags of its own words when it's loaded (check cmdTagOverrides()), so only the generic words are left. The apps
+// can give more with Detector.SetTagOverrides().
 var nlp_static_word_tags map[string]string = map[string]string{
 	//////////////////////////
 	// Generic words
 	"do":          "VBP",
-	"mode":        "NN",
-	"bluetooth":   "NN",
 	"it":          "NN", // To recognize "it" as a name, not a pronoun, because it will be replaced by the name of the
 	// last name, and so that would be included in the middle of the "and" meaning (not supposed to be names there).
 	"please":      "RB",
 	"fast":        "JJ",
-
-	//////////////////////////
-	// Commands
-	"turn":   "VB",
-	"get":   "VB",
-	"switch":   "VB",
-	"put":   "VB",
-
-	"record": "VB", // For the commands, it's always a verb (recognized as name in "record the audio").
-
-	"reboot": "VB", // For the commands, it's always a verb (recognized as name in "shut down the phone and reboot it").
-	"restart":   "VB",
-
-	"shut":   "VB",
-
-	"start":  "VB", // For the commands, it's always a verb (recognized as name in "start recording it").
-	"begin":   "VB",
-	"initialize":   "VB",
-	"commence":   "VB",
-
-	"stop":   "VB", // For the commands, it's always a verb (recognized as name in "stop recording it").
-	"finish":   "VB",
-	"cease":   "VB",
-	"conclude":   "VB",
-	"terminate":   "VB",
-
-	"answer":   "VB",
-	"reply":   "VB",
-	"respond":   "VB",
-	"acknowledge":   "VB",
 }
 
 //////////////////////////
@@ -126,80 +119,187 @@ replaceAnds().
 – Params:
   - sentence – a pointer to the header of the created 'sentence' slice on the function mainInternal()
   - sentence_str – the string sent to mainInternal() but with the modifications done on sentenceNLPPreparation()
-  - nlp_meanings – a slice with 2 strings: the first is the meaning of the first "it" that may be found on the sentence
-	(the last noun detected from the output of this module), and the second is the meaning of the last "and" found
+  - cmds_set – the commands set of the detection, with the tagger to get the part-of-speech tags of the words from and
+    the tags to override its ones with
+  - nlp_meanings – a slice with 3 strings: the first is the meaning of the first "it" that may be found on the sentence
+	(the last noun detected from the output of this module), the second is the meaning of the last "and" found, and the
+	third is the meaning of the first "them" (the last coordinated names, separated by LAST_NAMES_SEPARATOR)
+  - words_origins – the origins of the words of the 'sentence'
+  - trace – the trace to record the POS tags and the substitutions on, or nil to not record them
 
 – Returns:
-  - the updated 'sentence_str' according to the also updated 'sentence'
+  - the meanings of the last "it", the last "and" and the last "them" found, to be used as 'nlp_meanings' on the next
+    call
+  - the origins of the words of the updated 'sentence' (the words put there by the analysis are marked as substituted)
 */
-func nlpAnalyzer(sentence *[]string, sentence_str string, nlp_meanings []string) []string {
+func nlpAnalyzer(sentence *[]string, sentence_str string, cmds_set *cmdsSet, nlp_meanings []string,
+	words_origins []wordOrigin, trace *DetectionTrace) ([]string, []wordOrigin) {
+	//log.Println("-----")
+
+	var nlp *nlpState = newNLPState(*sentence, sentence_str, cmds_set, nlp_meanings, words_origins, trace)
+	nlp.analyze(sentence)
+
+	//log.Println("---")
+	//log.Println(*sentence)
 	//log.Println("-----")
 
-	resetVariables()
+	return []string{nlp.last_it, nlp.last_and, nlp.lastNamesGroup()}, nlp.words_origins
+}
+
+/*
+newNLPState creates the state of an analysis of nlpAnalyzer(), with the tags of the words of the sentence.
 
-	//nlp_last_name_found = append(nlp_last_name_found, it_and)
+-----------------------------------------------------------
+
+– Params:
+  - sentence – the words of the sentence
+  - the other parameters – same as in nlpAnalyzer()
+
+– Returns:
+  - the state
+*/
+func newNLPState(sentence []string, sentence_str string, cmds_set *cmdsSet, nlp_meanings []string,
+	words_origins []wordOrigin, trace *DetectionTrace) *nlpState {
+	var nlp *nlpState = &nlpState{
+		words_origins: words_origins,
+		trace:         trace,
+	}
+
+	//nlp.last_name_found = append(nlp.last_name_found, it_and)
 
 	//log.Println("-----------------------------")
 	//log.Println(sentence_str)
 
-	// Create a new document with the default configuration
-	nlp_doc, _ := prose.NewDocument(sentence_str)
-
-	var tokens []prose.Token = nlp_doc.Tokens()
-
-	// len(tokens) on the condition so it's checked every time what is the length and there's no need to decrement it or
-	// increment it manually depending on the modifications to the tokens' slice.
-	for counter, token_text := 0, ""; counter < len(tokens); counter++ {
-		token_text = tokens[counter].Text
-		if token_text == "'s" {
-			// Remove all possessive cases - not helpful yet, so can remove them with no problems, as they just get
-			// things harder because "what's" goes to "what" and "'s" on the NLP tokens detector, but not on the
-			// 'sentence', so the loop doesn't work. Removing the "'s" from the tokens list might help. It doesn't
-			// matter for the time being (no command requires any use of the possessive case or similar).
-			// Note that nothing will be touched on the 'sentence'. Only on the tokens. This way the 'sentence' is still
-			// intact. It's just removed from the tokens to synchronize them with the 'sentence' and nothing else. If
-			// possible nothing would be removed... (don't like the idea too much, but no better ideas at the moment).
-
-			DelElemSLICES(&tokens, counter)
-			// Decrement the counter, so we go to the previous, to then be incremented by the loop and go to the
-			// current one, which is now the old next one.
-			counter--
-		} else if new_tag, ok := nlp_static_word_tags[token_text]; ok {
-			tokens[counter].Tag = new_tag
-		}
+	var tokens []TaggedToken = cmds_set.getTagger().Tag(sentence_str)
+	for counter := range tokens {
+		tokens[counter].Tag = cmds_set.overriddenTag(tokens[counter].Text, tokens[counter].Tag)
 	}
+	// The tagger may split the words differently from the sentence (like "wifi." into "wifi" and "."), so the tags are
+	// given to the words here once, and then kept along with them - instead of going through the tokens and the words at
+	// the same time, which would get out of sync.
+	nlp.words_tags = alignTokensTags(sentence, tokens)
 
-	//log.Println(*sentence)
+	//log.Println(sentence)
 
-	// Print all the tokens
-	//for _, tok := range tokens {
-	//	log.Println(tok)
-	//}
+	if trace != nil {
+		for word_index, word := range sentence {
+			trace.Pos_tags = append(trace.Pos_tags, TraceTag{Word: word, Tag: nlp.words_tags[word_index]})
+		}
+	}
 
-	prev_sentence_it = nlp_meanings[0]
-	prev_sentence_and = nlp_meanings[1]
+	nlp.prev_sentence_it = nlp_meanings[0]
+	nlp.prev_sentence_and = nlp_meanings[1]
 
-	if "" != prev_sentence_it {
-		nlp_last_name_found = strings.Split(prev_sentence_it, " ")
+	if "" != nlp.prev_sentence_it {
+		nlp.last_name_found = strings.Split(nlp.prev_sentence_it, " ")
 	}
-	if "" != prev_sentence_and {
-		nlp_second_last_to_last_non_allowed_tag = strings.Split(prev_sentence_and, " ")
+	if "" != nlp.prev_sentence_and {
+		nlp.second_last_to_last_non_allowed_tag = strings.Split(nlp.prev_sentence_and, " ")
 	}
+	if len(nlp_meanings) > 2 && "" != nlp_meanings[2] {
+		for _, name := range strings.Split(nlp_meanings[2], LAST_NAMES_SEPARATOR) {
+			nlp.names_group = append(nlp.names_group, strings.Split(name, " "))
+		}
+		nlp.last_coordinated_group = nlp.names_group
+	}
+
+	//log.Println("nlp.prev_sentence_it:", nlp.prev_sentence_it)
+	//log.Println("nlp.prev_sentence_and:", nlp.prev_sentence_and)
 
-	//log.Println("prev_sentence_it:", prev_sentence_it)
-	//log.Println("prev_sentence_and:", prev_sentence_and)
+	return nlp
+}
 
+/*
+NLPAnalysisInternal runs only the NLP analysis on a sentence that is already prepared (lowercase words separated by one
+space), with no previous command information, and returns the words of the sentence after it with their part-of-speech
+tags and the spans they came from on the sentence. It's for checking that the tags and the spans stay with the right
+words through all the changes the analysis does.
+
+Note: if you find this function exported, know it's just for testing from the main package. Do NOT use it in production.
+*/
+func NLPAnalysisInternal(sentence_str string, tagger Tagger) ([]string, []string, []TextSpan) {
+	var sentence []string = strings.Split(sentence_str, " ")
+	var nlp *nlpState = newNLPState(sentence, sentence_str, &cmdsSet{tagger: tagger}, []string{"", "", ""},
+		sentenceWordsOrigins(sentence_str), nil)
+	nlp.analyze(&sentence)
+
+	var spans []TextSpan = nil
+	for _, word_origin := range nlp.words_origins {
+		spans = append(spans, word_origin.span)
+	}
+
+	return sentence, nlp.words_tags, spans
+}
+
+/*
+analyze replaces the "it"s and the "and"s of the sentence by their meaning, as explained on nlpAnalyzer().
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence – same as in nlpAnalyzer()
+
+– Returns:
+  - nothing
+*/
+func (nlp *nlpState) analyze(sentence *[]string) {
 	// The sentence_counter was already set before, so no setting it here on the loop (empty part).
-	for ; nlp_sentence_counter < len(*sentence); nlp_sentence_counter, nlp_token_counter = nlp_sentence_counter+1, nlp_token_counter+1 {
-		replaceIts(sentence, &tokens)
-		replaceAnds(sentence, &tokens)
+	for ; nlp.sentence_counter < len(*sentence); nlp.sentence_counter++ {
+		nlp.replaceIts(sentence)
+		nlp.replaceAnds(sentence)
+	}
+}
+
+/*
+replaceWord replaces the current word of the sentence by the given words (the meaning of an "it" or an "and"), giving
+them the origin of the replaced word marked as substituted, and moves the sentence counter to the last of them (so that
+the next word checked is the next old one, and the newly added words are not checked).
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence – same as in nlpAnalyzer()
+  - words – the words
+  - tag – the part-of-speech tag of the words ("" if unknown)
+  - reason – why it's replaced (for the trace)
+
+– Returns:
+  - nothing
+*/
+func (nlp *nlpState) replaceWord(sentence *[]string, words []string, tag string, reason string) {
+	nlp.trace.addSubstitution(nlp.sentence_counter, (*sentence)[nlp.sentence_counter], words, reason)
+	var word_origin wordOrigin = wordOrigin{span: nlp.words_origins[nlp.sentence_counter].span, substituted: true}
+	(*sentence)[nlp.sentence_counter] = words[0]
+	nlp.words_tags[nlp.sentence_counter] = tag
+	nlp.words_origins[nlp.sentence_counter] = word_origin
+	for word_index, word := range words[1:] {
+		// +1 below because we're starting from [1:].
+		AddElemSLICES(sentence, word, nlp.sentence_counter+word_index+1)
+		AddElemSLICES(&nlp.words_tags, tag, nlp.sentence_counter+word_index+1)
+		AddElemSLICES(&nlp.words_origins, word_origin, nlp.sentence_counter+word_index+1)
 	}
+	nlp.sentence_counter += len(words) - 1
+}
 
-	//log.Println("---")
-	//log.Println(*sentence)
-	//log.Println("-----")
+/*
+deleteWord deletes the current word of the sentence, and decrements the sentence counter so that the next word checked
+is the one after the deleted one.
 
-	return []string{nlp_last_it, nlp_last_and}
+-----------------------------------------------------------
+
+– Params:
+  - sentence – same as in nlpAnalyzer()
+  - reason – why it's deleted (for the trace)
+
+– Returns:
+  - nothing
+*/
+func (nlp *nlpState) deleteWord(sentence *[]string, reason string) {
+	nlp.trace.addSubstitution(nlp.sentence_counter, (*sentence)[nlp.sentence_counter], nil, reason)
+	DelElemSLICES(sentence, nlp.sentence_counter)
+	DelElemSLICES(&nlp.words_tags, nlp.sentence_counter)
+	DelElemSLICES(&nlp.words_origins, nlp.sentence_counter)
+	nlp.sentence_counter--
 }
 
 const WHATS_IT string = ";6;"
@@ -225,12 +325,11 @@ must not be removed.
 
 – Params:
   - sentence – same as in nlpAnalyzer()
-  - tokens – a list with the tokens of all the 'sentence' words
 
 – Returns:
   - nothing
 */
-func replaceIts(sentence *[]string, tokens *[]prose.Token) {
+func (nlp *nlpState) replaceIts(sentence *[]string) {
 	// Leave the 2 parameters because both exist on CmdsDetector(), so why create one of them again and not use the one
 	// that already exists? Optimization.
 
@@ -238,81 +337,316 @@ func replaceIts(sentence *[]string, tokens *[]prose.Token) {
 	// "turn it on turn on wifi and the airplane mode get it it on no don't turn it on turn off airplane mode and the " +
 	//	"wifi turn it on"
 
+	if nlp.replacePlurals(sentence) {
+		nlp.last_was_an_it = false
+
+		return
+	}
+
 	// Leave len(*sentence) there and don't assign a variable to it. That way it keeps checking the length, and it's not
 	// needed to increase or decrease based on changes on the 'sentence' (it will calculate the length every time).
-	if (*sentence)[nlp_sentence_counter] == "it" {
+	if (*sentence)[nlp.sentence_counter] == "it" || nlp.isOnePronoun(*sentence) {
 		//log.Println("-------")
-		//log.Println(nlp_sentence_counter)
-		//log.Println(nlp_last_was_an_it)
-		if nlp_last_was_an_it {
+		//log.Println(nlp.sentence_counter)
+		//log.Println(nlp.last_was_an_it)
+		if nlp.last_was_an_it {
 			// If the last word was an "it", it means there are repeated ones - delete all the repeated ones and use
 			// only the first one. If they were not deleted, too many words would be in between the command words -->
 			// no detection.
-			DelElemSLICES(sentence, nlp_sentence_counter)
-			nlp_sentence_counter-- // And since an element was deleted, decrement the sentence_counter.
+			// And since an element was deleted, the sentence_counter is decremented.
+			nlp.deleteWord(sentence, "repeated \"it\"")
 			//log.Println("*****")
 			//log.Println(*sentence)
 
 			return // And go to the next word on the sentence.
 		}
-		nlp_last_was_an_it = true
-		if len(nlp_last_name_found) > 0 {
-			//log.Println((*sentence)[nlp_sentence_counter])
-			//log.Println(nlp_last_name_found[0][0])
-			(*sentence)[nlp_sentence_counter] = nlp_last_name_found[0]
-			if len(nlp_last_name_found) > 1 {
-				for name_index, name := range nlp_last_name_found[1:] {
-					// +1 below because we're starting from [1:].
-					AddElemSLICES(sentence, name, nlp_sentence_counter+name_index+1)
-				}
+		nlp.last_was_an_it = true
+		if len(nlp.last_name_found) > 0 {
+			//log.Println((*sentence)[nlp.sentence_counter])
+			//log.Println(nlp.last_name_found[0][0])
+			var name []string = nlp.last_name_found
+			var reason string = "the last name before it"
+			if (*sentence)[nlp.sentence_counter] == "one" &&
+					strings.HasPrefix(nlp.words_tags[nlp.sentence_counter-1], "J") {
+				// The "one" comes with its own modifiers ("a rear one"), so it's only the head of the last name (its
+				// last word) - the words before it would be the modifiers of the last one ("frontal picture", if the
+				// tagger sees "frontal" as a name).
+				name = name[len(name)-1:]
+				reason = "the head of the last name before it"
 			}
-			// Increment the sentence_counter. -1 because it needs to stay at the element before the next. The next
-			// one will be taken care of by the sentence_counter++ line. This is done because we just added elements
-			// to the sentence. So the counter must be incremented to go to the next old element, not go through the
-			// newly added ones (that would also not be in accordance with the tokens iteration).
-			nlp_sentence_counter += len(nlp_last_name_found) - 1
+			nlp.replaceWord(sentence, name, "NN", reason)
 
 			//log.Println(*sentence)
 		} else {
 			//log.Println("RRRRRRRRRRRRRRRRRRRRRRRRRRRRR1")
 			var whats_it = WHATS_IT
-			if "" != prev_sentence_it {
-				whats_it = prev_sentence_it
-				prev_sentence_it = ""
+			var reason string = "nothing it refers to"
+			if "" != nlp.prev_sentence_it {
+				whats_it = nlp.prev_sentence_it
+				reason = "the last name of the previous sentence"
+				nlp.prev_sentence_it = ""
 			}
 
-			whats_it_list := strings.Split(whats_it, " ")
-			(*sentence)[nlp_sentence_counter] = whats_it_list[0]
-			if len(whats_it_list) > 1 {
-				for name_index, name := range whats_it_list[1:] {
-					AddElemSLICES(sentence, name, nlp_sentence_counter+name_index+1)
-				}
-			}
-			nlp_sentence_counter += len(whats_it_list) - 1
+			nlp.replaceWord(sentence, strings.Split(whats_it, " "), "NN", reason)
 		}
 	} else {
-		nlp_last_was_an_it = false
-		if strings.HasPrefix((*tokens)[nlp_token_counter].Tag, "N") { // Which means it's a name
-			if nlp_non_name_passed_since_last_name {
+		nlp.last_was_an_it = false
+		if strings.HasPrefix(nlp.words_tags[nlp.sentence_counter], "N") { // Which means it's a name
+			if nlp.non_name_passed_since_last_name {
 				// If a non-name passed since the last name, first empty the slice before appending - because on the
 				// slice are only consecutive names (like "airplane mode" - 2 names, that are put on the slice).
-				nlp_last_name_found = nil
+				nlp.last_name_found = nil
 				// Don't reset the name until a new name passes by. That way, this, for example, works: "the wifi
 				// turn it on now turn it off".
 			}
-			nlp_non_name_passed_since_last_name = false
+			if nlp.non_name_passed_since_last_name || len(nlp.names_group) == 0 {
+				// A new noun phrase - coordinated with the last ones if an "and" passed since them and no verb ("the
+				// wifi and the bluetooth", but not "the wifi and turn on the bluetooth").
+				if !nlp.and_passed_since_last_name || nlp.verb_passed_since_last_name {
+					nlp.names_group = nil
+				}
+				// The noun phrase starts on the adjectives right before the name, if any ("mobile data" is one name, not
+				// "data").
+				var noun_phrase []string = nil
+				for i := nlp.sentence_counter - 1; i >= 0 && strings.HasPrefix(nlp.words_tags[i], "J"); i-- {
+					noun_phrase = append([]string{(*sentence)[i]}, noun_phrase...)
+				}
+				nlp.names_group = append(nlp.names_group, noun_phrase)
+				nlp.and_passed_since_last_name = false
+				nlp.verb_passed_since_last_name = false
+			}
+			nlp.non_name_passed_since_last_name = false
 
-			nlp_last_name_found = append(nlp_last_name_found, (*sentence)[nlp_sentence_counter])
+			nlp.last_name_found = append(nlp.last_name_found, (*sentence)[nlp.sentence_counter])
+			nlp.names_group[len(nlp.names_group)-1] = append(nlp.names_group[len(nlp.names_group)-1],
+				(*sentence)[nlp.sentence_counter])
+			if len(nlp.names_group) >= 2 {
+				nlp.last_coordinated_group = nlp.names_group
+			}
 		} else {
-			if nlp_last_name_found != nil {
+			if nlp.last_name_found != nil {
 				// If a word that is a not a name passed since the last consecutive name, signal it to know that the
 				// next time a name is detected, it's not just to add it to the slice - first empty the slice.
-				nlp_non_name_passed_since_last_name = true
+				nlp.non_name_passed_since_last_name = true
+			}
+			var word string = (*sentence)[nlp.sentence_counter]
+			if word == "and" || word == "or" {
+				nlp.and_passed_since_last_name = true
+			} else if strings.HasPrefix(nlp.words_tags[nlp.sentence_counter], "VB") {
+				nlp.verb_passed_since_last_name = true
 			}
 		}
 	}
 
-	nlp_last_it = strings.Join(nlp_last_name_found, " ")
+	nlp.last_it = strings.Join(nlp.last_name_found, " ")
+}
+
+// one_pronoun_determiners are the words that make a "one" right after them stand for a name, besides the determiners and
+// the adjectives (as they may be tagged otherwise - "that" as a preposition, for example).
+var one_pronoun_determiners map[string]bool = map[string]bool{
+	"that":     true,
+	"this":     true,
+	"next":     true,
+	"previous": true,
+}
+
+/*
+isOnePronoun checks if the current word of the sentence is a "one" that stands for the last name said, like an "it",
+but with its own determiner or modifiers: "that one", "the next one", "a rear one" (on "take a frontal picture and a
+rear one", it's the picture, so "a rear picture").
+
+It's not a pronoun if it's not after a determiner or an adjective ("turn on one") or if it's before a name ("the one
+song").
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence – same as in nlpAnalyzer(), but not a pointer
+
+– Returns:
+  - true if it's a "one" standing for a name, false otherwise
+*/
+func (nlp *nlpState) isOnePronoun(sentence []string) bool {
+	if sentence[nlp.sentence_counter] != "one" || nlp.sentence_counter == 0 {
+		return false
+	}
+	if nlp.sentence_counter+1 < len(sentence) && strings.HasPrefix(nlp.words_tags[nlp.sentence_counter+1], "N") {
+		return false
+	}
+
+	var prev_tag string = nlp.words_tags[nlp.sentence_counter-1]
+
+	return strings.HasPrefix(prev_tag, "DT") || strings.HasPrefix(prev_tag, "J") ||
+		one_pronoun_determiners[sentence[nlp.sentence_counter-1]]
+}
+
+// LAST_NAMES_SEPARATOR separates the names of the last coordinated noun phrases on the previous command information
+// ("wifi+airplane mode").
+const LAST_NAMES_SEPARATOR string = "+"
+
+/*
+replacePlurals replaces the plural pronoun ("them", "they", "both" or "all of them" and "both of them") on the current
+word of the sentence by its meaning: the names of the last coordinated noun phrases before it (last_coordinated_group),
+each with the action of the pronoun, so that there's one command per name. Without coordinated names before it, it
+means nothing (like an "it" without names before it) - even if there are separate names before it, as on "turn on the
+wifi then turn on the bluetooth and turn them off".
+
+For example, "turn on the wifi and the bluetooth then turn them off" --> "them" refers to "wifi" and "bluetooth", and is
+replaced by "wifi off turn bluetooth" - being the final sentence "... then turn wifi off turn bluetooth off". The action
+is the one before the pronoun (the same as the meaning of an "and") and the words after it until the next verb, "and" or
+"then".
+
+"both" is only a pronoun if not followed by a name or a determiner ("turn both off", but not "turn on both the wifi and
+the bluetooth"). And "they" is only replaced after an action verb ("turn they off", as the speech recognition may hear
+it) - else it's the subject of a sentence that is not a command ("they should be off").
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence – same as in nlpAnalyzer()
+
+– Returns:
+  - true if the current word was a plural pronoun (and was replaced), false otherwise
+*/
+func (nlp *nlpState) replacePlurals(sentence *[]string) bool {
+	var next_words []string = (*sentence)[nlp.sentence_counter+1:]
+	var pronoun_len int = 0
+	switch (*sentence)[nlp.sentence_counter] {
+		case "them", "they":
+			pronoun_len = 1
+		case "all", "both":
+			if len(next_words) >= 2 && next_words[0] == "of" && next_words[1] == "them" {
+				pronoun_len = 3
+			} else if (*sentence)[nlp.sentence_counter] == "both" && (len(next_words) == 0 ||
+					!strings.HasPrefix(nlp.words_tags[nlp.sentence_counter+1], "N") &&
+					!strings.HasPrefix(nlp.words_tags[nlp.sentence_counter+1], "DT") && next_words[0] != "the") {
+				pronoun_len = 1
+			}
+	}
+	if pronoun_len == 0 || (*sentence)[nlp.sentence_counter] == "they" && !nlp.actionVerbPassed(*sentence) {
+		return false
+	}
+
+	// Make the whole pronoun one word, with the origin of all of it.
+	var pronoun string = strings.Join((*sentence)[nlp.sentence_counter:nlp.sentence_counter+pronoun_len], " ")
+	var pronoun_span TextSpan = TextSpan{
+		Start: nlp.words_origins[nlp.sentence_counter].span.Start,
+		End:   nlp.words_origins[nlp.sentence_counter+pronoun_len-1].span.End,
+	}
+	for i := 1; i < pronoun_len; i++ {
+		nlp.deleteWord(sentence, "part of \""+pronoun+"\"")
+		nlp.sentence_counter++
+	}
+	nlp.words_origins[nlp.sentence_counter].span = pronoun_span
+	next_words = (*sentence)[nlp.sentence_counter+1:]
+
+	var names [][]string = distinctNames(nlp.last_coordinated_group)
+	if len(names) == 0 {
+		nlp.replaceWord(sentence, []string{WHATS_IT}, "NN", "nothing it refers to")
+
+		return true
+	}
+
+	var action []string = nil
+	if nlp.verbs_passed == 1 {
+		action = nlp.second_last_to_last_non_allowed_tag
+	}
+	var action_end []string = nil
+	for i, word := range next_words {
+		if word == "and" || word == "then" || strings.HasPrefix(nlp.words_tags[nlp.sentence_counter+1+i], "VB") {
+			break
+		}
+		action_end = append(action_end, word)
+	}
+
+	var meaning []string = CopyOuterSLICES(names[0])
+	for _, name := range names[1:] {
+		meaning = append(meaning, action_end...)
+		meaning = append(meaning, action...)
+		meaning = append(meaning, name...)
+	}
+	// All tagged as names, as the meaning of an "it" (the analysis doesn't look at them again, and so the action goes on
+	// after them).
+	nlp.replaceWord(sentence, meaning, "NN", "the last coordinated names before it")
+
+	return true
+}
+
+/*
+lastNamesGroup gets the names of the last coordinated noun phrases, for the next call of nlpAnalyzer().
+
+-----------------------------------------------------------
+
+– Returns:
+  - the different names separated by LAST_NAMES_SEPARATOR, or "" if there are less than 2
+*/
+func (nlp *nlpState) lastNamesGroup() string {
+	var names []string = nil
+	for _, name := range distinctNames(nlp.last_coordinated_group) {
+		names = append(names, strings.Join(name, " "))
+	}
+	if len(names) < 2 {
+		return ""
+	}
+
+	return strings.Join(names, LAST_NAMES_SEPARATOR)
+}
+
+/*
+distinctNames removes the repeated names of a group of names ("the wifi and the bluetooth and the wifi" --> only one
+"wifi").
+
+-----------------------------------------------------------
+
+– Params:
+  - names_group – the group of names
+
+– Returns:
+  - the names, each only once, in the order they first appear
+*/
+func distinctNames(names_group [][]string) [][]string {
+	var names [][]string = nil
+	var names_found map[string]bool = make(map[string]bool)
+	for _, name := range names_group {
+		var name_str string = strings.Join(name, " ")
+		if !names_found[name_str] {
+			names_found[name_str] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// non_action_verbs are the verbs that don't mean an action for a command by themselves ("they should be off").
+var non_action_verbs map[string]bool = map[string]bool{
+	"be": true, "is": true, "are": true, "am": true, "was": true, "were": true, "been": true, "being": true,
+	"do": true, "does": true, "did": true, "have": true, "has": true, "had": true,
+}
+
+/*
+actionVerbPassed checks if the last action of the sentence (second_last_to_last_non_allowed_tag) is still going on and
+has a verb that means an action ("turn", but not "are").
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence – same as in nlpAnalyzer(), but not a pointer
+
+– Returns:
+  - true if an action verb passed, false otherwise
+*/
+func (nlp *nlpState) actionVerbPassed(sentence []string) bool {
+	if nlp.verbs_passed != 1 || len(nlp.last_action_tags) != len(nlp.second_last_to_last_non_allowed_tag) {
+		return false
+	}
+	for i, tag := range nlp.last_action_tags {
+		if strings.HasPrefix(tag, "VB") && !non_action_verbs[nlp.second_last_to_last_non_allowed_tag[i]] {
+			return true
+		}
+	}
+
+	return false
 }
 
 /*
@@ -336,12 +670,11 @@ turn off the airplane mode".
 
 – Params:
   - sentence – same as in nlpAnalyzer()
-  - tokens – a list with the tokens of all the 'sentence' words
 
 – Returns:
   - nothing
 */
-func replaceAnds(sentence *[]string, tokens *[]prose.Token) {
+func (nlp *nlpState) replaceAnds(sentence *[]string) {
 	// Leave the 2 parameters because both exist on CmdsDetector(), so why create one of them again and not use the one
 	// that already exists? Optimization.
 
@@ -349,97 +682,94 @@ func replaceAnds(sentence *[]string, tokens *[]prose.Token) {
 	// "turn on wifi and and the airplane mode and the flashlight"
 	// When the implementation is changed, swap the places of "on" and "wifi" and check if it still works.
 
-	if (*sentence)[nlp_sentence_counter] == "and" {
-		if nlp_last_was_an_and ||
-			((len(*tokens) > nlp_sentence_counter+1) && strings.HasPrefix((*tokens)[nlp_sentence_counter+1].Tag, "VB")) ||
-			((len(*tokens) > nlp_sentence_counter+2) && strings.HasPrefix((*tokens)[nlp_sentence_counter+2].Tag, "VB")) {
+	if (*sentence)[nlp.sentence_counter] == "and" {
+		if nlp.last_was_an_and ||
+			((len(nlp.words_tags) > nlp.sentence_counter+1) && strings.HasPrefix(nlp.words_tags[nlp.sentence_counter+1], "VB")) ||
+			((len(nlp.words_tags) > nlp.sentence_counter+2) && strings.HasPrefix(nlp.words_tags[nlp.sentence_counter+2], "VB")) {
 			// The same as for the "it" case.
 			// Except here also delete if the next word is a verb: "shut down the phone and reboot it". Here, "and" is
 			// not supposed to be replaced by "shut down". Instead, its presence is irrelevant. So just remove it,
 			// because the next word is a verb (means after it is said the actual action and not to repeat the previous
 			// one).
 			// Also with +2 because "and then reboot". The verb is the 2nd word here, not the 1st.
-			DelElemSLICES(sentence, nlp_sentence_counter)
-			nlp_sentence_counter--
+			nlp.deleteWord(sentence, "repeated \"and\" or followed by a verb")
 			//log.Println("*****")
 			//log.Println(*sentence)
 
 			return
 		}
-		nlp_last_was_an_and = true
+		nlp.last_was_an_and = true
 
 		//log.Println("------")
-		//log.Println(nlp_second_last_to_last_non_allowed_tag)
-
-		if len(nlp_second_last_to_last_non_allowed_tag) > 0 {
-			//log.Println(nlp_sentence_counter)
-			(*sentence)[nlp_sentence_counter] = nlp_second_last_to_last_non_allowed_tag[0]
-			if len(nlp_second_last_to_last_non_allowed_tag) > 1 {
-				for non_name_index, non_name := range nlp_second_last_to_last_non_allowed_tag[1:] {
-					// +1 below because we're starting from [1:].
-					AddElemSLICES(sentence, non_name, nlp_sentence_counter+non_name_index+1)
-				}
+		//log.Println(nlp.second_last_to_last_non_allowed_tag)
+
+		if len(nlp.second_last_to_last_non_allowed_tag) > 0 {
+			//log.Println(nlp.sentence_counter)
+			var action []string = nlp.second_last_to_last_non_allowed_tag
+			if nlp.newModifiersAfter(*sentence) {
+				// "take a frontal picture and a rear one" --> "take a", not "take a frontal", as "rear" replaces it.
+				action = nlp.actionWithoutModifiers()
 			}
-			nlp_sentence_counter += len(nlp_second_last_to_last_non_allowed_tag) - 1
+			// The words of the action are not tagged - they're not looked at again.
+			nlp.replaceWord(sentence, action, "", "the last action before it")
 
 			// This -1 makes it so that as it found an "and", it will stop adding words to the list but will not discard
 			// or erase them.
-			nlp_verbs_passed = -1
+			nlp.verbs_passed = -1
 
 			// No deletions here as with "it". What was before the "and" remains there to still have impact (unlike with
 			// "it" in which the names are just in the wrong place for the verification function to work properly).
 		} else {
 			//log.Println("RRRRRRRRRRRRRRRRRRRRRRRRRRRRR2")
 			var whats_and = WHATS_AND
-			if "" != prev_sentence_and {
-				whats_and = prev_sentence_and
-				prev_sentence_and = ""
+			var reason string = "nothing it refers to"
+			if "" != nlp.prev_sentence_and {
+				whats_and = nlp.prev_sentence_and
+				reason = "the last action of the previous sentence"
+				nlp.prev_sentence_and = ""
 			}
 
-			whats_and_list := strings.Split(whats_and, " ")
-			(*sentence)[nlp_sentence_counter] = whats_and_list[0]
-			if len(whats_and_list) > 1 {
-				for non_name_index, non_name := range whats_and_list[1:] {
-					AddElemSLICES(sentence, non_name, nlp_sentence_counter+non_name_index+1)
-				}
-			}
-			nlp_sentence_counter += len(whats_and_list) - 1
+			nlp.replaceWord(sentence, strings.Split(whats_and, " "), "", reason)
 		}
 	} else {
-		nlp_last_was_an_and = false
-		var current_tag string = (*tokens)[nlp_token_counter].Tag
+		nlp.last_was_an_and = false
+		var current_tag string = nlp.words_tags[nlp.sentence_counter]
 		if !strings.HasPrefix(current_tag, "N") {
 			if strings.HasPrefix(current_tag, "VB") {
-				if nlp_verbs_passed < 0 {
-					nlp_verbs_passed = 0
+				if nlp.verbs_passed < 0 {
+					nlp.verbs_passed = 0
 				}
-				nlp_verbs_passed++
-				if nlp_verbs_passed == 1 {
+				nlp.verbs_passed++
+				if nlp.verbs_passed == 1 {
 					// Reset the slice if a new verb is found. Useful for the first time in which a verb is detected
 					// and a slice had been passed as previous command information.
-					nlp_second_last_to_last_non_allowed_tag = nil
+					nlp.second_last_to_last_non_allowed_tag = nil
+					nlp.last_action_tags = nil
 				}
 			}
-			if nlp_non_allowed_tag_passed_since_last_allowed || nlp_verbs_passed > 1 {
+			if nlp.non_allowed_tag_passed_since_last_allowed || nlp.verbs_passed > 1 {
 				// If a non-allowed tag passed since the last allowed one, empty the slice before appending - because on
 				// the slice are only consecutive allowed tags' words (like "turn on" - 2 allowed tags' words, that are
 				// put on the slice).
-				nlp_second_last_to_last_non_allowed_tag = nil
+				nlp.second_last_to_last_non_allowed_tag = nil
+				nlp.last_action_tags = nil
 				// Don't reset the slice until a new allowed tags' word passes by. That way, this, for example, works:
 				// "turn on the wifi and the airplane mode and the flashlight".
-				if nlp_verbs_passed > 1 {
-					nlp_verbs_passed = 1 // Verb just passed, so set to 1
+				if nlp.verbs_passed > 1 {
+					nlp.verbs_passed = 1 // Verb just passed, so set to 1
 				}
 			}
-			nlp_non_allowed_tag_passed_since_last_allowed = false
+			nlp.non_allowed_tag_passed_since_last_allowed = false
 
-			if nlp_verbs_passed == 1 {
+			if nlp.verbs_passed == 1 {
 				if strings.HasPrefix(current_tag, "VB") {
 					var adjectives_list []string = nil
-					for i := nlp_sentence_counter - 1; i >= 0; i-- {
-						if strings.HasPrefix((*tokens)[i].Tag, "J") {
+					var adjectives_tags []string = nil
+					for i := nlp.sentence_counter - 1; i >= 0; i-- {
+						if strings.HasPrefix(nlp.words_tags[i], "J") {
 							// Add all adjectives right behind the current word in case it's a verb.
 							adjectives_list = append(adjectives_list, (*sentence)[i])
+							adjectives_tags = append(adjectives_tags, nlp.words_tags[i])
 						} else {
 							// Stop when a non-adjective is found (must be consecutive adjectives).
 							break
@@ -447,38 +777,76 @@ func replaceAnds(sentence *[]string, tokens *[]prose.Token) {
 					}
 					for i := len(adjectives_list)-1; i >= 0; i-- {
 						// Add all adjectives in the order they were inserted in the sentence.
-						nlp_second_last_to_last_non_allowed_tag = append(nlp_second_last_to_last_non_allowed_tag,
+						nlp.second_last_to_last_non_allowed_tag = append(nlp.second_last_to_last_non_allowed_tag,
 							adjectives_list[i])
+						nlp.last_action_tags = append(nlp.last_action_tags, adjectives_tags[i])
 					}
 				}
-				nlp_second_last_to_last_non_allowed_tag = append(nlp_second_last_to_last_non_allowed_tag,
-					(*sentence)[nlp_sentence_counter])
+				nlp.second_last_to_last_non_allowed_tag = append(nlp.second_last_to_last_non_allowed_tag,
+					(*sentence)[nlp.sentence_counter])
+				nlp.last_action_tags = append(nlp.last_action_tags, current_tag)
 			}
 		}
 	}
 
-	nlp_last_and = strings.Join(nlp_second_last_to_last_non_allowed_tag, " ")
+	nlp.last_and = strings.Join(nlp.second_last_to_last_non_allowed_tag, " ")
+}
+
+/*
+newModifiersAfter checks if the name after the current "and" of the sentence comes with its own adjectives ("and a rear
+one", "and the rear picture"), which then replace the ones of the name of the last action.
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence – same as in nlpAnalyzer(), but not a pointer
+
+– Returns:
+  - true if there's an adjective between the "and" and the next word that is not a determiner or an adjective, false
+    otherwise
+*/
+func (nlp *nlpState) newModifiersAfter(sentence []string) bool {
+	for i := nlp.sentence_counter + 1; i < len(sentence); i++ {
+		var tag string = nlp.words_tags[i]
+		if strings.HasPrefix(tag, "J") {
+			return true
+		}
+		if !strings.HasPrefix(tag, "DT") && !strings.HasPrefix(tag, "PRP$") {
+			break
+		}
+	}
+
+	return false
 }
 
 /*
-resetVariables resets the global variables used in this file every time it's called.
+actionWithoutModifiers gets the last action (second_last_to_last_non_allowed_tag) without the adjectives of its name -
+the ones after its last verb ("take a frontal" --> "take a"; the ones before the verb, as "fast" on "fast reboot", are
+kept).
+
+-----------------------------------------------------------
+
+– Returns:
+  - the action (the same one if its tags are not known)
 */
-func resetVariables() {
-	nlp_sentence_counter = 0
-	nlp_token_counter = 0
+func (nlp *nlpState) actionWithoutModifiers() []string {
+	if len(nlp.last_action_tags) != len(nlp.second_last_to_last_non_allowed_tag) {
+		return nlp.second_last_to_last_non_allowed_tag
+	}
 
-	// For replaceIts()
-	nlp_last_was_an_it = false
-	nlp_non_name_passed_since_last_name = false
-	nlp_last_name_found = nil
-	nlp_last_it = ""
-	prev_sentence_it = ""
+	var last_verb_index int = -1
+	for i, tag := range nlp.last_action_tags {
+		if strings.HasPrefix(tag, "VB") {
+			last_verb_index = i
+		}
+	}
 
-	// For replaceAnds()
-	nlp_last_was_an_and = false
-	nlp_non_allowed_tag_passed_since_last_allowed = false
-	nlp_verbs_passed = 0
-	nlp_second_last_to_last_non_allowed_tag = nil
-	nlp_last_and = ""
-	prev_sentence_and = ""
+	var action []string = nil
+	for i, word := range nlp.second_last_to_last_non_allowed_tag {
+		if i <= last_verb_index || !strings.HasPrefix(nlp.last_action_tags[i], "J") {
+			action = append(action, word)
+		}
+	}
+
+	return action
 }
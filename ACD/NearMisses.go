@@ -0,0 +1,88 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+/*
+nearMiss gets what was found of a command that was triggered by a main word but not detected (no condition had all its
+words found).
+
+The variations returned are the ones with the most words found, of those accepted by the main words return conditions
+for the main word.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmd – the command
+  - results – the return of wordsVerificationFunction() for the command
+  - main_word – the main word that triggered the command
+  - main_word_span – the span on the original sentence of the main word
+  - words_origins – same as in sentenceCmdsDetector()
+
+– Returns:
+  - the near miss
+  - true if the command has any variation accepted for the main word, false otherwise (not a near miss)
+*/
+func nearMiss(cmd commandInfo, results []conditionMatch, main_word string, main_word_span TextSpan,
+	words_origins []wordOrigin) (NearMiss, bool) {
+	var near_miss NearMiss = NearMiss{
+		Cmd_id:         cmd.cmd_id,
+		Main_word_span: main_word_span,
+	}
+	var biggest_len int = -1
+	for condition, condition_match := range results {
+		if !mainWordAccepted(condition, main_word, cmd) {
+			continue
+		}
+
+		var words_found int = condition_match.wordsFound()
+		if words_found < biggest_len {
+			continue
+		}
+		if words_found > biggest_len {
+			near_miss.Variations = nil
+			biggest_len = words_found
+		}
+
+		var variation NearMissVariation = NearMissVariation{
+			Sub_cmd_index: cmd.variants[condition],
+			Words_spans:   matchedWordsSpans(words_origins, condition_match),
+		}
+		var groups_found []bool = make([]bool, len(cmd.words_list[condition].words_groups))
+		for _, word_match := range condition_match.words_matches {
+			if word_match.found && word_match.group >= 0 {
+				groups_found[word_match.group] = true
+			}
+			if word_match.index >= 0 {
+				variation.Words = append(variation.Words, word_match.word)
+			}
+		}
+		// The verification stops on the first group not found, so the ones after it are missing too.
+		for group, words_group := range cmd.words_list[condition].words_groups {
+			if !groups_found[group] && len(words_group.words) > 0 && !words_group.isOptional() {
+				variation.Missing_groups = append(variation.Missing_groups, words_group.words)
+			}
+		}
+		near_miss.Variations = append(near_miss.Variations, variation)
+	}
+
+	return near_miss, len(near_miss.Variations) > 0
+}
@@ -0,0 +1,203 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"sort"
+)
+
+// cmdCandidate is a command detected on a sentence by sentenceCmdsDetectorOptimal(), before choosing the ones to
+// return.
+type cmdCandidate struct {
+	detected_cmd detectedCmd
+	// start_index is the index on the sentence of the first word used on the detection
+	start_index int
+	// end_index is the index on the sentence of the last word used on the detection
+	end_index int
+	// num_words is the number of words of the sentence used on the detection (the main word ones and the ones found)
+	num_words int
+}
+
+/*
+sentenceCmdsDetectorOptimal is the same as sentenceCmdsDetector(), but instead of detecting the commands from left to
+right, with each detection taking its words from the next ones (with 'invalidate_detec_words'), it first gets all the
+commands that can be detected on the sentence (the candidates) and then chooses the ones that don't overlap and that
+use the most words of the sentence, together.
+
+For example, with a command triggered by "turn" with the words "on" and another with the words "on wifi", on "turn on
+the wifi" sentenceCmdsDetector() detects the first one (it's checked first and takes the "on"), but this function
+detects the second one (3 words against 2).
+
+Each candidate takes all the words from the first to the last it used on the sentence, and the special commands are
+candidates too (so a command that uses the "don't" of "i don't" is chosen over the special command, as it uses more
+words). On ties, the candidates ending first are preferred.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmds_set – same as in sentenceCmdsDetector()
+  - sentence – same as in sentenceCmdsDetector()
+  - words_origins – same as in sentenceCmdsDetector()
+  - report_ambiguities – same as in sentenceCmdsDetector()
+  - near_misses – same as in sentenceCmdsDetector() (only of the main words that are the beginning of no candidate)
+  - trace – same as in sentenceCmdsDetector() (the candidates not chosen are not recorded as such)
+
+– Returns:
+  - same as in sentenceCmdsDetector()
+*/
+func sentenceCmdsDetectorOptimal(cmds_set *cmdsSet, sentence []string, words_origins []wordOrigin,
+	report_ambiguities bool, near_misses *[]NearMiss, trace *DetectionTrace) []detectedCmd {
+	var candidates []cmdCandidate = nil
+	for sentence_counter := range sentence {
+		if special_cmd, num_words, ok := specialCmdAt(sentence, sentence_counter); ok {
+			special_cmd.main_word_span = wordsSpan(words_origins, sentence_counter, sentence_counter+num_words-1)
+			candidates = append(candidates, cmdCandidate{
+				detected_cmd: special_cmd,
+				start_index:  sentence_counter,
+				end_index:    sentence_counter + num_words - 1,
+				num_words:    num_words,
+			})
+
+			continue
+		}
+
+		var main_word_matches []mainWordMatch = cmds_set.main_words_trie.matchesAt(sentence, sentence_counter)
+		trace.addMainWords(sentence_counter, main_word_matches, cmds_set.cmds)
+		var num_candidates int = len(candidates)
+		var main_word_near_misses []NearMiss = nil
+		for _, main_word_match := range main_word_matches {
+			var ambiguity_candidates []ambiguityCandidate = nil
+			if report_ambiguities {
+				ambiguity_candidates = mainWordCandidates(cmds_set, sentence, words_origins, sentence_counter,
+					main_word_match)
+			}
+			for _, i := range main_word_match.cmds_indexes {
+				var cmd commandInfo = cmds_set.cmds[i]
+				var results []conditionMatch = wordsVerificationFunction(sentence, sentence_counter,
+					main_word_match.end_index, cmd)
+				var final_cond int = checkMainWordsRetConds(results, main_word_match.main_word, cmd)
+				trace.addVerification(cmd, main_word_match.main_word, sentence_counter, results, final_cond)
+				if final_cond == -1 {
+					if near_misses != nil {
+						if near_miss, ok := nearMiss(cmd, results, main_word_match.main_word, wordsSpan(words_origins,
+							sentence_counter, main_word_match.end_index), words_origins); ok {
+							main_word_near_misses = append(main_word_near_misses, near_miss)
+						}
+					}
+
+					continue
+				}
+
+				var candidate cmdCandidate = cmdCandidate{
+					detected_cmd: detectedCmd{
+						cmd_id:        cmd.cmd_id,
+						sub_cmd_index: cmd.variants[final_cond],
+						score: detectionScore(cmd, final_cond, results[final_cond], main_word_match, sentence_counter,
+							words_origins),
+						main_word_span: wordsSpan(words_origins, sentence_counter, main_word_match.end_index),
+						words_spans:    matchedWordsSpans(words_origins, results[final_cond]),
+					},
+					start_index:  sentence_counter,
+					end_index:    main_word_match.end_index,
+					num_words:    main_word_match.end_index - sentence_counter + 1,
+				}
+				candidate.detected_cmd.candidates = tiedCandidates(ambiguity_candidates, candidate.detected_cmd,
+					results[final_cond].wordsFound())
+				for _, word_match := range results[final_cond].words_matches {
+					if word_match.index < 0 {
+						continue
+					}
+					if word_match.index < candidate.start_index {
+						candidate.start_index = word_match.index
+					}
+					if word_match.index > candidate.end_index {
+						candidate.end_index = word_match.index
+					}
+					candidate.num_words++
+				}
+				candidates = append(candidates, candidate)
+			}
+		}
+		if len(candidates) == num_candidates && near_misses != nil {
+			*near_misses = append(*near_misses, main_word_near_misses...)
+		}
+	}
+
+	var detected_cmds []detectedCmd = nil
+	for _, candidate := range chooseCandidates(candidates) {
+		detected_cmds = append(detected_cmds, candidate.detected_cmd)
+	}
+
+	return detected_cmds
+}
+
+/*
+chooseCandidates chooses the candidates that don't overlap and that use the most words, together (weighted interval
+scheduling, with dynamic programming).
+
+-----------------------------------------------------------
+
+– Params:
+  - candidates – all the candidates (reordered)
+
+– Returns:
+  - the chosen candidates, in the order they are on the sentence
+*/
+func chooseCandidates(candidates []cmdCandidate) []cmdCandidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].end_index < candidates[j].end_index
+	})
+
+	// previousCandidates is the number of candidates that end before the given candidate begins - as they're sorted by
+	// the end, they're the first ones.
+	var previousCandidates = func(candidate int) int {
+		return sort.Search(candidate, func(i int) bool {
+			return candidates[i].end_index >= candidates[candidate].start_index
+		})
+	}
+
+	// best_num_words[k] is the most words the first k candidates can use without overlapping.
+	var best_num_words []int = make([]int, len(candidates)+1)
+	for k := range candidates {
+		best_num_words[k+1] = best_num_words[k]
+		if num_words := candidates[k].num_words + best_num_words[previousCandidates(k)]; num_words > best_num_words[k] {
+			best_num_words[k+1] = num_words
+		}
+	}
+
+	// Go back through the choices made to get the chosen candidates.
+	var chosen []cmdCandidate = nil
+	for k := len(candidates); k > 0; {
+		var previous int = previousCandidates(k - 1)
+		if candidates[k-1].num_words+best_num_words[previous] > best_num_words[k-1] {
+			chosen = append(chosen, candidates[k-1])
+			k = previous
+		} else {
+			k--
+		}
+	}
+	sort.Slice(chosen, func(i, j int) bool {
+		return chosen[i].start_index < chosen[j].start_index
+	})
+
+	return chosen
+}
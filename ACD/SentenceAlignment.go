@@ -0,0 +1,275 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TextSpan is a part of the original sentence given to Detect(): the bytes from Start to End (End excluded), so that
+// it's sentence_str[Start:End].
+type TextSpan struct {
+	Start int
+	End   int
+}
+
+// wordOrigin is where a word of the sentence came from on the original sentence, after the corrections and the NLP
+// analysis changed the sentence.
+type wordOrigin struct {
+	// span is the part of the original sentence the word came from (for words that replaced others, the span of the ones
+	// replaced - like "what's" from "what is", or the meaning of an "it" from the "it")
+	span TextSpan
+	// substituted is true if the word was put on the sentence by the NLP analysis (the meaning of an "it" or an "and")
+	substituted bool
+}
+
+/*
+sentenceWordsOrigins gets the origins of the words of a sentence not yet changed (each word is its own origin).
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence_str – the sentence
+
+– Returns:
+  - the origin of each word of the sentence split by spaces (the same way as the sentence is split for the detection)
+*/
+func sentenceWordsOrigins(sentence_str string) []wordOrigin {
+	var words_origins []wordOrigin = nil
+	var start int = 0
+	for _, word := range strings.Split(sentence_str, " ") {
+		words_origins = append(words_origins, wordOrigin{span: TextSpan{start, start + len(word)}})
+		start += len(word) + 1
+	}
+
+	return words_origins
+}
+
+/*
+alignWords gets the origins of the words of a sentence after it was changed, from the origins of the words before the
+change.
+
+The words that stayed the same keep their origins (they're matched with the longest common subsequence of words). The
+ones that changed get the origin of all the words they replaced (like "what's" from "what is", or "never" and "mind" from
+"nevermind"), and the ones only added get an empty span where they were added.
+
+-----------------------------------------------------------
+
+– Params:
+  - old_words – the words before the change
+  - old_origins – the origins of the 'old_words'
+  - new_words – the words after the change
+
+– Returns:
+  - the origins of the 'new_words'
+*/
+func alignWords(old_words []string, old_origins []wordOrigin, new_words []string) []wordOrigin {
+	// common_len[i][j] is the length of the longest common subsequence of old_words[i:] and new_words[j:].
+	var common_len [][]int = make([][]int, len(old_words)+1)
+	for i := range common_len {
+		common_len[i] = make([]int, len(new_words)+1)
+	}
+	for i := len(old_words) - 1; i >= 0; i-- {
+		for j := len(new_words) - 1; j >= 0; j-- {
+			if old_words[i] == new_words[j] {
+				common_len[i][j] = common_len[i+1][j+1] + 1
+			} else if common_len[i+1][j] >= common_len[i][j+1] {
+				common_len[i][j] = common_len[i+1][j]
+			} else {
+				common_len[i][j] = common_len[i][j+1]
+			}
+		}
+	}
+
+	var new_origins []wordOrigin = make([]wordOrigin, len(new_words))
+	// The words between 2 common ones (or the ends) were changed: old_words[old_gap:i] to new_words[new_gap:j].
+	var old_gap int = 0
+	var new_gap int = 0
+	var alignGap = func(i int, j int) {
+		var gap_origin wordOrigin = wordOrigin{}
+		if i > old_gap {
+			gap_origin = old_origins[old_gap]
+			for _, old_origin := range old_origins[old_gap+1 : i] {
+				gap_origin.span.End = old_origin.span.End
+				gap_origin.substituted = gap_origin.substituted || old_origin.substituted
+			}
+		} else if old_gap > 0 {
+			gap_origin.span = TextSpan{old_origins[old_gap-1].span.End, old_origins[old_gap-1].span.End}
+		}
+		for k := new_gap; k < j; k++ {
+			new_origins[k] = gap_origin
+		}
+	}
+	var i int = 0
+	var j int = 0
+	for i < len(old_words) && j < len(new_words) {
+		if old_words[i] == new_words[j] && common_len[i][j] == common_len[i+1][j+1]+1 {
+			alignGap(i, j)
+			new_origins[j] = old_origins[i]
+			i++
+			j++
+			old_gap, new_gap = i, j
+		} else if common_len[i+1][j] >= common_len[i][j+1] {
+			i++
+		} else {
+			j++
+		}
+	}
+	alignGap(len(old_words), len(new_words))
+
+	return new_origins
+}
+
+/*
+wordsSpan gets the span on the original sentence of consecutive words of the sentence.
+
+-----------------------------------------------------------
+
+– Params:
+  - words_origins – the origins of the words of the sentence (nil if they're not known)
+  - first_index – the index of the first word
+  - last_index – the index of the last word
+
+– Returns:
+  - the span from the beginning of the first word to the end of the last, or an empty span if the origins are not known
+*/
+func wordsSpan(words_origins []wordOrigin, first_index int, last_index int) TextSpan {
+	if first_index < 0 || last_index >= len(words_origins) {
+		return TextSpan{}
+	}
+
+	var span TextSpan = words_origins[first_index].span
+	for _, word_origin := range words_origins[first_index+1 : last_index+1] {
+		if word_origin.span.Start < span.Start {
+			span.Start = word_origin.span.Start
+		}
+		if word_origin.span.End > span.End {
+			span.End = word_origin.span.End
+		}
+	}
+
+	return span
+}
+
+/*
+matchedWordsSpans gets the spans on the original sentence of the words found for a condition.
+
+-----------------------------------------------------------
+
+– Params:
+  - words_origins – same as in wordsSpan()
+  - condition_match – the match of the condition, from wordsVerificationFunction()
+
+– Returns:
+  - the spans, in the order of the original sentence and without repetitions (words put by the NLP analysis in place of
+    the same "and" have the same span, for example), or nil if the origins are not known
+*/
+func matchedWordsSpans(words_origins []wordOrigin, condition_match conditionMatch) []TextSpan {
+	var spans []TextSpan = nil
+	for _, word_match := range condition_match.words_matches {
+		if word_match.index < 0 || word_match.index >= len(words_origins) {
+			continue
+		}
+
+		var span TextSpan = words_origins[word_match.index].span
+		var insert_index int = len(spans)
+		for k, other_span := range spans {
+			if other_span == span {
+				insert_index = -1
+
+				break
+			}
+			if span.Start < other_span.Start {
+				insert_index = k
+
+				break
+			}
+		}
+		if insert_index != -1 {
+			spans = append(spans, TextSpan{})
+			copy(spans[insert_index+1:], spans[insert_index:])
+			spans[insert_index] = span
+		}
+	}
+
+	return spans
+}
+
+/*
+alignTokensTags gets the part-of-speech tag of each word of a sentence from the tokens of a tagger, which may split the
+words differently ("wifi." into "wifi" and ".", "phone's" into "phone" and "'s") and even skip or change some.
+
+Each token is looked for on the sentence after the previous one found, and belongs to the word it starts in. A word with
+more than one token gets the tag of its first one with a letter or a digit ("wifi" and not "."), or of its first one if
+none has any. The tokens not found on the sentence are ignored, and the words with no tokens get no tag ("").
+
+-----------------------------------------------------------
+
+– Params:
+  - words – the words of the sentence
+  - tokens – the tokens of the sentence
+
+– Returns:
+  - the tag of each word
+*/
+func alignTokensTags(words []string, tokens []TaggedToken) []string {
+	var words_tags []string = make([]string, len(words))
+	var words_tagged_by_letters []bool = make([]bool, len(words))
+	var words_tagged []bool = make([]bool, len(words))
+
+	var sentence_str string = strings.Join(words, " ")
+	var position int = 0
+	var word_index int = 0
+	var word_end int = 0
+	if len(words) > 0 {
+		word_end = len(words[0])
+	}
+	for _, token := range tokens {
+		if token.Text == "" {
+			continue
+		}
+		var index int = strings.Index(sentence_str[position:], token.Text)
+		if index < 0 {
+			continue
+		}
+		var token_start int = position + index
+		position = token_start + len(token.Text)
+
+		// Go to the word the token starts in (word_end is its end, not counting the space after it).
+		for token_start > word_end && word_index < len(words)-1 {
+			word_index++
+			word_end += 1 + len(words[word_index])
+		}
+
+		var has_letters bool = strings.IndexFunc(token.Text, func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r)
+		}) >= 0
+		if !words_tagged[word_index] || (has_letters && !words_tagged_by_letters[word_index]) {
+			words_tags[word_index] = token.Tag
+			words_tagged[word_index] = true
+			words_tagged_by_letters[word_index] = has_letters
+		}
+	}
+
+	return words_tags
+}
@@ -0,0 +1,472 @@
+/*******************************************************************************
+ * Copyright 2023-2025 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jdkato/prose/v2"
+)
+
+// Tagger gives the part-of-speech tags of the words of a sentence to the NLP analysis, which uses them to know what
+// the "it"s and the "and"s refer to. The tags are the Penn Treebank ones ("NN" for names, "VB" for verbs, "JJ" for
+// adjectives...) - the analysis only looks at their beginning ("N", "VB" and "J").
+//
+// Each detector uses one tagger (ProseTagger by default), chosen with Detector.SetTagger() or
+// Detector.UseLexiconTagger(). The tags of nlp_static_word_tags are always applied over the ones of the tagger.
+type Tagger interface {
+	// Tag tags the words of a sentence (lowercase words separated by one space). It returns the tokens of the sentence
+	// in order, which may split the words differently ("wifi." into "wifi" and "."), as long as their text is on the
+	// sentence - the analysis gives each word the tag of its tokens (check alignTokensTags()). It may be called
+	// concurrently.
+	Tag(sentence_str string) []TaggedToken
+}
+
+// TaggedToken is a word of a sentence with its part-of-speech tag.
+type TaggedToken struct {
+	// Text is the word
+	Text string
+	// Tag is the Penn Treebank tag of the word
+	Tag string
+}
+
+// ProseTagger is the Tagger that uses the Prose library (the statistical tagger the NLP analysis always used). It's
+// the most accurate on sentences that are not only commands, but it's heavy to load and slow.
+type ProseTagger struct{}
+
+/*
+Tag is the implementation of Tagger.Tag().
+*/
+func (ProseTagger) Tag(sentence_str string) []TaggedToken {
+	// Create a new document with the default configuration
+	nlp_doc, _ := prose.NewDocument(sentence_str)
+
+	var tokens []TaggedToken = nil
+	for _, token := range nlp_doc.Tokens() {
+		tokens = append(tokens, TaggedToken{Text: token.Text, Tag: token.Tag})
+	}
+
+	return tokens
+}
+
+// LexiconTagger is a fast and deterministic Tagger, which tags the words with a lexicon of verbs and names (usually the
+// vocabulary of the commands - check Detector.UseLexiconTagger()) and a few rules. It gives exactly one token per word
+// of the sentence, and "turn" or "record" are never names on it, as they're verbs on the commands.
+//
+// The words are tagged by this order:
+//   - the words of nlp_static_word_tags and of lexicon_function_words_tags (articles, pronouns, prepositions...) with
+//     their tag there
+//   - digits as "CD"
+//   - the words right before a "one" after an article, a possessive or an adjective as "JJ" ("a rear one")
+//   - the verbs of the lexicon as "VB", except right after an article or a possessive - then "JJ" ("the next song"), or
+//     "NN" if they're names of the lexicon too ("the power") - and when they're names of the lexicon too and come after
+//     a preposition, an adjective or a name ("turn on power saver") - then "NN"
+//   - the names of the lexicon as "NN", or "VBG" if they end in "-ing" and come right after a verb ("stop recording
+//     it")
+//   - other words by their ending: "-ing" as "VBG", "-ed" as "VBN", "-ly" as "RB", and the rest as "NN"
+type LexiconTagger struct {
+	// verbs are the verbs of the lexicon
+	verbs map[string]bool
+	// names are the names of the lexicon
+	names map[string]bool
+}
+
+// lexicon_function_words_tags are the tags of the closed classes of words (the ones that don't depend on the commands)
+// for the LexiconTagger.
+var lexicon_function_words_tags map[string]string = map[string]string{
+	// Articles and determiners
+	"the": "DT", "a": "DT", "an": "DT", "this": "DT", "that": "DT", "these": "DT", "those": "DT", "all": "DT",
+	"some": "DT", "any": "DT", "every": "DT", "each": "DT", "both": "DT",
+	// Pronouns
+	"i": "PRP", "you": "PRP", "he": "PRP", "she": "PRP", "we": "PRP", "they": "PRP", "me": "PRP", "him": "PRP",
+	"us": "PRP", "them": "PRP", "my": "PRP$", "your": "PRP$", "his": "PRP$", "her": "PRP$", "its": "PRP$",
+	"our": "PRP$", "their": "PRP$",
+	// Question words
+	"what": "WP", "who": "WP", "which": "WDT", "how": "WRB", "when": "WRB", "where": "WRB", "why": "WRB",
+	// Conjunctions
+	"and": "CC", "or": "CC", "but": "CC", "nor": "CC",
+	// Prepositions and particles
+	"on": "IN", "off": "IN", "in": "IN", "at": "IN", "of": "IN", "for": "IN", "with": "IN", "from": "IN", "by": "IN",
+	"about": "IN", "into": "IN", "down": "RP", "up": "RP", "out": "RP", "over": "IN", "to": "TO",
+	// Adverbs
+	"not": "RB", "n't": "RB", "now": "RB", "again": "RB", "also": "RB", "too": "RB", "then": "RB", "just": "RB",
+	"very": "RB", "really": "RB", "here": "RB", "there": "RB",
+	// Numbers
+	"one": "CD", "two": "CD", "three": "CD",
+	// Interjections
+	"no": "UH", "yes": "UH", "ok": "UH", "okay": "UH", "hey": "UH", "hi": "UH", "hello": "UH",
+	// Modals and auxiliaries
+	"will": "MD", "would": "MD", "can": "MD", "could": "MD", "shall": "MD", "should": "MD", "may": "MD",
+	"might": "MD", "must": "MD", "is": "VBZ", "are": "VBP", "am": "VBP", "was": "VBD", "were": "VBD", "be": "VB",
+	"been": "VBN", "does": "VBZ", "did": "VBD", "have": "VBP", "has": "VBZ", "had": "VBD",
+}
+
+/*
+NewLexiconTagger creates a LexiconTagger with the given lexicon.
+
+-----------------------------------------------------------
+
+– Params:
+  - verbs – the verbs of the lexicon
+  - names – the names of the lexicon
+
+– Returns:
+  - the new tagger
+*/
+func NewLexiconTagger(verbs []string, names []string) *LexiconTagger {
+	var tagger *LexiconTagger = &LexiconTagger{
+		verbs: make(map[string]bool, len(verbs)),
+		names: make(map[string]bool, len(names)),
+	}
+	for _, verb := range verbs {
+		tagger.verbs[verb] = true
+	}
+	for _, name := range names {
+		tagger.names[name] = true
+	}
+
+	return tagger
+}
+
+/*
+newCmdsLexiconTagger creates a LexiconTagger with the vocabulary of a list of commands: their main words (the trigger
+words of their types included) are the verbs, and the words of their conditions are the names.
+
+-----------------------------------------------------------
+
+– Params:
+  - cmds – the commands
+
+– Returns:
+  - the new tagger
+*/
+func newCmdsLexiconTagger(cmds []commandInfo) *LexiconTagger {
+	var verbs []string = nil
+	var names []string = nil
+	for _, cmd := range cmds {
+		for _, main_word := range cmd.main_words {
+			verbs = append(verbs, strings.Split(main_word, MAIN_WORDS_PHRASE_SEP)...)
+		}
+		for _, condition := range cmd.words_list {
+			for _, words_group := range condition.words_groups {
+				for _, word := range words_group.words {
+					if word != NONE && word != IS_DIGIT {
+						names = append(names, word)
+					}
+				}
+			}
+		}
+	}
+
+	return NewLexiconTagger(verbs, names)
+}
+
+/*
+Tag is the implementation of Tagger.Tag().
+*/
+func (tagger *LexiconTagger) Tag(sentence_str string) []TaggedToken {
+	var words []string = strings.Split(sentence_str, " ")
+	var tokens []TaggedToken = make([]TaggedToken, 0, len(words))
+	var prev_tag string = ""
+	for word_index, word := range words {
+		// Punctuation stays attached to the words on the sentence, so ignore it to find the word on the lexicon.
+		var lexicon_word string = strings.TrimFunc(word, unicode.IsPunct)
+		var next_word string = ""
+		if word_index+1 < len(words) {
+			next_word = strings.TrimFunc(words[word_index+1], unicode.IsPunct)
+		}
+
+		var tag string = ""
+		if static_tag, ok := nlp_static_word_tags[lexicon_word]; ok {
+			tag = static_tag
+		} else if function_word_tag, ok := lexicon_function_words_tags[lexicon_word]; ok {
+			tag = function_word_tag
+		} else if lexicon_word != "" && strings.IndexFunc(lexicon_word, func(r rune) bool {
+			return !unicode.IsDigit(r)
+		}) < 0 {
+			tag = "CD"
+		} else if next_word == "one" && (prev_tag == "DT" || prev_tag == "PRP$" || strings.HasPrefix(prev_tag, "J")) {
+			// Describes the name the "one" stands for ("a rear one").
+			tag = "JJ"
+		} else if tagger.verbs[lexicon_word] {
+			tag = "VB"
+			if prev_tag == "DT" || prev_tag == "PRP$" {
+				// A verb doesn't come after an article - so it's a name ("the power") or it describes the name
+				// after it ("the next song").
+				tag = "JJ"
+				if tagger.names[lexicon_word] {
+					tag = "NN"
+				}
+			} else if tagger.names[lexicon_word] && (prev_tag == "IN" || strings.HasPrefix(prev_tag, "J") ||
+					strings.HasPrefix(prev_tag, "N")) {
+				tag = "NN"
+			}
+		} else if tagger.names[lexicon_word] {
+			tag = "NN"
+			if strings.HasPrefix(prev_tag, "VB") && len(lexicon_word) > 4 && strings.HasSuffix(lexicon_word, "ing") {
+				// Right after a verb, it's a verb too ("stop recording it", but "recording audio" is a name).
+				tag = "VBG"
+			}
+		} else {
+			switch {
+				case len(lexicon_word) > 4 && strings.HasSuffix(lexicon_word, "ing"):
+					tag = "VBG"
+				case len(lexicon_word) > 3 && strings.HasSuffix(lexicon_word, "ed"):
+					tag = "VBN"
+				case len(lexicon_word) > 3 && strings.HasSuffix(lexicon_word, "ly"):
+					tag = "RB"
+				default:
+					tag = "NN"
+			}
+		}
+
+		tokens = append(tokens, TaggedToken{Text: word, Tag: tag})
+		prev_tag = tag
+	}
+
+	return tokens
+}
+
+/*
+getTagger gets the tagger of the NLP analysis of the commands set.
+
+-----------------------------------------------------------
+
+– Returns:
+  - the tagger
+*/
+func (cmds_set *cmdsSet) getTagger() Tagger {
+	if cmds_set.tagger == nil {
+		return ProseTagger{}
+	}
+
+	return cmds_set.tagger
+}
+
+/*
+SetTagger calls Detector.SetTagger() on the default detector.
+*/
+func SetTagger(tagger Tagger) {
+	default_detector_GL.SetTagger(tagger)
+}
+
+/*
+UseLexiconTagger calls Detector.UseLexiconTagger() on the default detector.
+*/
+func UseLexiconTagger() {
+	default_detector_GL.UseLexiconTagger()
+}
+
+/*
+SetTagger sets the tagger the NLP analysis of the detector uses to get the part-of-speech tags of the words. The
+detections already running keep using the previous one.
+
+-----------------------------------------------------------
+
+– Params:
+  - tagger – the tagger, or nil to go back to the default one (ProseTagger)
+
+– Returns:
+  - nothing
+*/
+func (detector *Detector) SetTagger(tagger Tagger) {
+	_ = detector.updateCmdsSet(func(cmds_set *cmdsSet) error {
+		cmds_set.tagger = tagger
+		cmds_set.lexicon_tagger = false
+
+		return nil
+	})
+}
+
+/*
+UseLexiconTagger makes the NLP analysis of the detector use a LexiconTagger with the vocabulary of its commands - their
+main words as verbs and the words of their conditions as names. The tagger is rebuilt each time the commands change.
+
+Much faster than ProseTagger and with no model to load (good for Android), but it only knows the words of the commands
+and a few rules - so it's best for sentences made mostly of commands.
+
+-----------------------------------------------------------
+
+– Returns:
+  - nothing
+*/
+func (detector *Detector) UseLexiconTagger() {
+	_ = detector.updateCmdsSet(func(cmds_set *cmdsSet) error {
+		cmds_set.lexicon_tagger = true
+
+		return nil
+	})
+}
+
+/*
+SetTagOverrides calls Detector.SetTagOverrides() on the default detector.
+*/
+func SetTagOverrides(tag_overrides map[string]string) error {
+	return default_detector_GL.SetTagOverrides(tag_overrides)
+}
+
+/*
+SetTagOverridesStr calls Detector.SetTagOverridesStr() on the default detector.
+*/
+func SetTagOverridesStr(tag_overrides_str string) error {
+	return default_detector_GL.SetTagOverridesStr(tag_overrides_str)
+}
+
+/*
+SetTagOverrides sets part-of-speech tags to always give to some words on the NLP analysis of the detector, over the ones
+of the tagger and of the commands (check mergeCmdsTagOverrides()) - for words the commands don't tag as needed.
+
+Setting them replaces the ones set before.
+
+-----------------------------------------------------------
+
+– Params:
+  - tag_overrides – the Penn Treebank tags ("VB", "NN"...) by word, or nil to remove all
+
+– Returns:
+  - nil if the tags were set, or an error with all the problems found on them otherwise (nothing is set then)
+*/
+func (detector *Detector) SetTagOverrides(tag_overrides map[string]string) error {
+	var errs []error = nil
+	// Keep a copy, so that the caller can't change it anymore.
+	var new_tag_overrides map[string]string = make(map[string]string, len(tag_overrides))
+	for word, tag := range tag_overrides {
+		if word == "" || strings.ContainsAny(word, " ") {
+			errs = append(errs, fmt.Errorf("tag override of %q: the word must not be empty nor have spaces", word))
+		}
+		if tag == "" || strings.ContainsAny(tag, " ") {
+			errs = append(errs, fmt.Errorf("tag override of %q: the tag %q must not be empty nor have spaces", word,
+				tag))
+		}
+		new_tag_overrides[word] = tag
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	return detector.updateCmdsSet(func(cmds_set *cmdsSet) error {
+		cmds_set.tag_overrides = new_tag_overrides
+
+		return nil
+	})
+}
+
+/*
+SetTagOverridesStr is the same as SetTagOverrides(), but with the tags in a string (for when maps can't be used).
+
+-----------------------------------------------------------
+
+– Params:
+  - tag_overrides_str – the tags in the format "word:tag word:tag...". Example: "record:VB mode:NN"
+
+– Returns:
+  - same as in SetTagOverrides()
+*/
+func (detector *Detector) SetTagOverridesStr(tag_overrides_str string) error {
+	var tag_overrides map[string]string = make(map[string]string)
+	for _, tag_override := range strings.Fields(tag_overrides_str) {
+		word, tag, ok := strings.Cut(tag_override, ":")
+		if !ok {
+			return fmt.Errorf("tag override %q: not in the format \"word:tag\"", tag_override)
+		}
+		tag_overrides[word] = tag
+	}
+
+	return detector.SetTagOverrides(tag_overrides)
+}
+
+/*
+mergeCmdsTagOverrides merges the part-of-speech tags the commands give to their words (check cmdTagOverrides()). The
+words the commands don't agree on get no tag, as it depends on the sentence - like "power", which is a verb on "power
+off the phone" and a name on "turn on power saver".
+
+-----------------------------------------------------------
+
+– Params:
+  - cmds – the commands
+
+– Returns:
+  - the tags, by word
+*/
+func mergeCmdsTagOverrides(cmds []commandInfo) map[string]string {
+	var tag_overrides map[string]string = make(map[string]string)
+	var disagreements map[string]bool = make(map[string]bool)
+	for _, cmd := range cmds {
+		for word, tag := range cmd.tag_overrides {
+			if prev_tag, ok := tag_overrides[word]; ok && prev_tag != tag {
+				disagreements[word] = true
+			}
+			tag_overrides[word] = tag
+		}
+	}
+	for word := range disagreements {
+		delete(tag_overrides, word)
+	}
+
+	return tag_overrides
+}
+
+// cmds_overridable_tags are the tags the ones of the commands replace: only the base forms of names and verbs, not the
+// inflected ones ("recording" as "VBG", "recorded" as "VBN"). "VBZ" too, as it's spelled as a plural name ("water the
+// plants", with "plants" seen as a verb).
+var cmds_overridable_tags map[string]bool = map[string]bool{
+	"NN":  true,
+	"NNS": true,
+	"VB":  true,
+	"VBP": true,
+	"VBZ": true,
+}
+
+/*
+overriddenTag gets the part-of-speech tag to use for a word on the NLP analysis of the commands set, given the one of
+the tagger.
+
+The tags given by the app are used first, then the ones of nlp_static_word_tags, and then the ones of the commands - but
+these last ones only replace the base tags of names and verbs (cmds_overridable_tags), as they're about the words being
+names or verbs: "the next song" keeps "next" as an adjective, even if "next" is a main word, and "stop recording it"
+keeps "recording" as a verb, even if it's a name on "recording audio" (else "it" would be the recording).
+
+-----------------------------------------------------------
+
+– Params:
+  - word – the word
+  - tag – the tag the tagger gave to the word
+
+– Returns:
+  - the tag to use
+*/
+func (cmds_set *cmdsSet) overriddenTag(word string, tag string) string {
+	if app_tag, ok := cmds_set.tag_overrides[word]; ok {
+		return app_tag
+	}
+	if static_tag, ok := nlp_static_word_tags[word]; ok {
+		return static_tag
+	}
+	if cmds_tag, ok := cmds_set.cmds_tag_overrides[word]; ok && cmds_overridable_tags[tag] {
+		return cmds_tag
+	}
+
+	return tag
+}
@@ -0,0 +1,63 @@
+/*******************************************************************************
+ * Copyright 2023-2024 Edw590
+ *
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ ******************************************************************************/
+
+package ACD
+
+import (
+	"strings"
+	"testing"
+)
+
+/*
+BenchmarkTaggers measures the whole detection (NLP analysis included) with each tagger - the lexicon one should be much
+faster than the Prose one.
+*/
+func BenchmarkTaggers(b *testing.B) {
+	const sentence_str string = "turn on the wifi and the bluetooth then take a picture"
+	const exp_cmds string = "4.00001, 6.00001, 15.00001"
+
+	var taggers = []struct {
+		name       string
+		use_tagger func(detector *Detector)
+	}{
+		{"lexicon", func(detector *Detector) { detector.UseLexiconTagger() }},
+		{"prose", func(detector *Detector) { detector.SetTagger(ProseTagger{}) }},
+	}
+	for _, tagger := range taggers {
+		b.Run(tagger.name, func(b *testing.B) {
+			var detector *Detector = NewDetector()
+			if err := detector.ReloadCmdsArray("4||" + CMDi_TYPE_TURN_ONFF + "||||||wifi\\6||" + CMDi_TYPE_TURN_ONFF +
+				"||||||bluetooth\\15||" + CMDi_TYPE_NONE + "||take||||picture/photo|frontal picture/photo"); err != nil {
+				b.Fatal("the commands were not loaded:", err)
+			}
+			tagger.use_tagger(detector)
+			var output string = detector.MainInternal(sentence_str, false, true, "|")
+			if cmds := output[strings.Index(output, INFO_CMDS_SEPARATOR)+len(INFO_CMDS_SEPARATOR):]; cmds != exp_cmds {
+				b.Fatalf("%q detected instead of %q", cmds, exp_cmds)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				detector.MainInternal(sentence_str, false, true, "|")
+			}
+		})
+	}
+}
@@ -24,7 +24,6 @@ package ACD
 import (
 	"bytes"
 	"encoding/gob"
-	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -39,15 +38,26 @@ import (
 const _MOD_RET_ERR_PREFIX = "3234_ACD_ERR"
 
 /*
-GetSubCmdIndex returns the index of a returned float by Main(), in the original command information array.
+GetSubCmdIndex returns the index of a returned command by Main(), in the original command information array.
 
-For example, for 10.00023, it multiplies ".00023" by MAX_SUB_CMDS and subtracts 1, which currently (100k as max) will
-return 22. Or for 10.0023, it will return 229.
+For example, for 10.00023, it takes "00023" and subtracts 1, which will return 22. Or for 10.0023, it will return 229
+(the missing trailing zeros are put back). The decoding is exact, so it works for any command ID.
+
+If the string is not a returned command (no decimal part, more than _SUB_CMD_DIGITS decimal digits, or something else
+than digits on it), -1 is returned.
 */
-func GetSubCmdIndex(returned_float string) int {
-	s, _ := strconv.ParseFloat("."+strings.Split(returned_float, ".")[1], 32)
+func GetSubCmdIndex(returned_cmd string) int {
+	_, decimal_part, found := strings.Cut(returned_cmd, ".")
+	if !found || decimal_part == "" || len(decimal_part) > _SUB_CMD_DIGITS ||
+			strings.IndexFunc(decimal_part, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return -1
+	}
+	sub_cmd, err := strconv.Atoi(decimal_part + strings.Repeat("0", _SUB_CMD_DIGITS-len(decimal_part)))
+	if err != nil || sub_cmd == 0 {
+		return -1
+	}
 
-	return int(math.Round(s*MAX_SUB_CMDS) - 1)
+	return sub_cmd - 1
 }
 
 // Exported functions above
@@ -22,7 +22,6 @@
 package ACD
 
 import (
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -81,6 +80,114 @@ const INDEX_DEFAULT string = ";3;"
 const NONE string = ";0;"
 const NOTHING_DETECTED = -1
 
+// wordMatch is the result of the search for a words group of a condition, on one sub-verification.
+type wordMatch struct {
+	// found is true if the words group was found (or is optional and was not on the sentence)
+	found bool
+	// index is the index of the word found on the sentence, or -1 if no word was found
+	index int
+	// word is the word found on the sentence, or NONE if no word was found
+	word string
+	// group is the index of the words group of the condition that was found (an optional one too), or -1 if none was
+	group int
+	// distance is how many words the word found is away from where its search began (0 if no word was found)
+	distance int
+	// window is the search interval on the side of the word found (the left or the right one)
+	window int
+}
+
+// conditionMatch is the result of the search for a condition, with one wordMatch per sub-verification done on it.
+type conditionMatch struct {
+	words_matches []wordMatch
+}
+
+/*
+allFound checks if all the words groups searched for the condition were found.
+
+-----------------------------------------------------------
+
+– Returns:
+  - true if all were found, false otherwise
+*/
+func (match conditionMatch) allFound() bool {
+	for _, word_match := range match.words_matches {
+		if !word_match.found {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+wordsFound counts the words actually found on the sentence for the condition (optional words groups that were not on the
+sentence don't count).
+
+-----------------------------------------------------------
+
+– Returns:
+  - the number of words found
+*/
+func (match conditionMatch) wordsFound() int {
+	var words_found int = 0
+	for _, word_match := range match.words_matches {
+		if word_match.word != NONE {
+			words_found++
+		}
+	}
+
+	return words_found
+}
+
+/*
+optionalWordsMapIndex finds an optional words group (one with NONE) allowed for a position index.
+
+-----------------------------------------------------------
+
+– Params:
+  - condition – the condition of the 'words_list'
+  - sub_verification – the position index
+
+– Returns:
+  - the index of the words group on the condition, or -1 if there's none
+*/
+func optionalWordsMapIndex(condition cmdCondition, sub_verification int) int {
+	for index_words_map, words_group := range condition.words_groups {
+		if words_group.allowedAt(sub_verification) && words_group.isOptional() {
+			return index_words_map
+		}
+	}
+
+	return -1
+}
+
+/*
+isMainWordAt checks if a main word is on a sentence beginning on an index.
+
+-----------------------------------------------------------
+
+– Params:
+  - sentence – the sentence
+  - index – the index
+  - main_word – the main word (a phrase if it has MAIN_WORDS_PHRASE_SEP)
+
+– Returns:
+  - true if all its words are on the sentence from the index on, false otherwise
+*/
+func isMainWordAt(sentence []string, index int, main_word string) bool {
+	if !strings.Contains(main_word, MAIN_WORDS_PHRASE_SEP) {
+		return sentence[index] == main_word
+	}
+
+	for i, word := range strings.Split(main_word, MAIN_WORDS_PHRASE_SEP) {
+		if index+i >= len(sentence) || sentence[index+i] != word {
+			return false
+		}
+	}
+
+	return true
+}
+
 /*
 wordsVerificationFunction iterates a sentence and searches for keywords provided on a list and returns the index of the
 words condition it detected.
@@ -123,12 +230,15 @@ completed on the current condition, and only in case no word was found.
 
 – Params:
   - sentence – same as in sentenceCmdsDetector()
-  - sentence_index – index on the sentence where to start the search
+  - main_word_index – index on the sentence of the first word of the main word that triggered the detection
+  - sentence_index – index on the sentence where to start the search: the last word of the main word (the same as
+    'main_word_index', except for phrases). The words of the main word are never searched.
   - main_words – 1D slice with the words that activated the command detection. Example: for commands pair
     "set the alarm"/"new alarm", 'main_words' is {"set", "new"}
 
-– words_list – a [][][][]interface{} that contains the words that the command accepts; their variations and with them,
-variations of the return command. Examples:
+– words_list – the conditions with the words that the command accepts; their variations and with them, variations of
+the return command. Examples (each condition written as {words groups} and each words group as {{allowed indexes},
+{words}} - check cmdCondition and wordsGroup):
 
 	{{{-1}, {"on"}},  {{-1}, {"wifi", "wi-fi"}}},
 	{{{-1}, {"off"}}, {{-1}, {"wifi", "wi-fi"}}},
@@ -148,19 +258,16 @@ variations of the return command. Examples:
 
 Structure and naming convention:
 
-- Each line (like "{{{-1}, {"on"}}, {{-1}, {"wifi", "wi-fi"}}},"): condition
+- Each line (like "{{{-1}, {"on"}}, {{-1}, {"wifi", "wi-fi"}}},"): condition (cmdCondition)
 
-- Each slice inside the line: words map (it was supposed to be a map, if the "key" weren't an array - to be possible to
-give multiple values)
+- Each element inside the line: words group (wordsGroup), with the allowed indexes list and the words
 
-- Each slice inside a word map (there can only be 0 or 2): the 1st is the allowed indexes list, the 2nd is the words
-group
-
-If a word map is empty, the function will disregard it and only check the non-empty ones.
+If a words group has no words, the function will disregard it and only check the others.
 
 Each condition with word groups containing NONE must be above all the ones that don't have NONE. As a secondary ordering
-rule, each condition with more non-empty maps than the others must be above them, or the function will not detect things
-correctly.
+rule, each condition with more words groups than the others must be above them, or the function will not detect things
+correctly. The conditions are reordered like this when loaded (normalizeConditionsOrder()), so there's no need
+to write them in this order.
 
 The list of allowed indexes can be an array of various numbers, being each number the position index on which the
 detected word must be for a successful detection. For example, "turn on the damn wifi" ("turn", a main word, doesn't
@@ -181,10 +288,11 @@ default value to all sub-verifications (default is 0). Example:
 In this case, the normal will be to use 3, except on the sub-verification 2 (3rd) in which 1 will be used, on the
 number 4 (5th) the default index will be used. Selecting 3 for all sub-verifications, for example means it will check
 the 3 words before the found word for the next word on the 'words_list'. Also, if ALL_SUB_VERIFS_STR is not used and a
-number is not used for a specific sub-verification, the default one will be used. Use a nil or empty slice to disregard
-this feature.
+number is not used for a specific sub-verification, the default one will be used. INDEX_EVEN and INDEX_ODD can be used
+too - a specific sub-verification number has priority over them, and they have priority over ALL_SUB_VERIFS_INT. Use a
+nil or empty slice to disregard this feature.
 
-  - right_intervs – same as for 'left_intervs', but for the right side. Default is 3.
+  - right_intervs – same as for 'left_intervs', but for the right side. Default is 5.
   - init_indexes_sub_verifs – a map in which each key X is the number of the sub-verification, and the value is the index
     on which to begin the specified sub-verification. Note: the 1st sub-verification (number 0) always starts on index 0, so
     attempts to change that sub-verification initial index will be ignored. The value can also be one of the constants. In
@@ -220,44 +328,24 @@ Note that this will not remove the word found (if it detects "off", it will only
 words - which excludes "off"). For that, use the 'exclude_word_found_group' parameter.
 
 – Returns:
-  - a 3D array with the first arrays being the number of command variations (len(words_list)), the second arrays being
-    the number of word maps in the command variation, and the third arrays are arrays of 2 elements only, with the 1st
-    a bool indicating if the word was detected or not, and the 2nd the index in which it was detected, or -1 if the 1st
-    index is false (no word detected)
+  - one conditionMatch per condition of 'words_list' (same order), each with one wordMatch per sub-verification done on
+    the condition (one per words group)
 */
-func wordsVerificationFunction(sentence []string, sentence_index int, cmd commandInfo) [][][]interface{} {
-
-	var success_detects [][][]interface{} = nil
-
-	// Make a copy of the 'words_list', so it doesn't get modified by this function as the copy will be. Must be a real
-	// copy (elements from sub-slices will be removed/added), so CopySlice().
-	var words_list [][][][]interface{} = nil
-	CopyFullSLICES(&words_list, cmd.words_list)
-	// And make a copy of the original words to use in the repeated words check. CopyOuterSlice() suffices, as it's just
-	// to copy each value of the slice (which are pointers - no problem with that as the contents won't be modified).
-	var original_main_words []string = CopyOuterSLICES(cmd.main_words)
-
-	// If it's to exclude all the original words from the 'words_list', do it here, before the sub-verifications begin.
-	if cmd.exclude_main_words {
-		for i, j := range words_list {
-			for ii, jj := range j {
-				if len(jj) == 0 {
-					// If this map is empty, go to the next one
-					continue
-				}
-
-				for iii, word := range jj[1] {
-					for _, main_word := range cmd.main_words {
-						if word == main_word {
-							DelElemSLICES(&words_list[i][ii][1], iii)
-
-							break
-						}
-					}
-				}
-			}
-		}
+func wordsVerificationFunction(sentence []string, main_word_index int, sentence_index int,
+	cmd commandInfo) []conditionMatch {
+
+	var success_detects []conditionMatch = nil
+
+	// The 'words_list' is shared with all the detections, so it's never modified. The words excluded during the
+	// verification are kept here instead: the ones excluded from all the conditions and the ones excluded from each
+	// condition. (The main words, if it's to exclude them, were already removed when the command was loaded.)
+	var words_list []cmdCondition = cmd.words_list
+	var excluded_words []string = nil
+	var conds_excluded_words [][]string = make([][]string, len(words_list))
+	var isExcluded = func(condition_index int, word string) bool {
+		return isInSlice(excluded_words, word) || isInSlice(conds_excluded_words[condition_index], word)
 	}
+	var original_main_words []string = cmd.main_words
 
 	var sentence_len int = len(sentence)
 	var num_conditions int = len(words_list)
@@ -265,15 +353,15 @@ func wordsVerificationFunction(sentence []string, sentence_index int, cmd comman
 	var left_intervs []int = nil
 	var right_intervs []int = nil
 	var max_sub_verifications int = 0
-	for _, j := range words_list {
-		if len(j) > max_sub_verifications {
-			max_sub_verifications = len(j)
+	for _, condition := range words_list {
+		if len(condition.words_groups) > max_sub_verifications {
+			max_sub_verifications = len(condition.words_groups)
 		}
-		success_detects = append(success_detects, nil)
+		success_detects = append(success_detects, conditionMatch{})
 	}
 	for i := 0; i < max_sub_verifications; i++ {
 		left_intervs = append(left_intervs, chooseCustomIntervals(cmd.left_intervs, i, 0))
-		right_intervs = append(right_intervs, chooseCustomIntervals(cmd.left_intervs, i, 5))
+		right_intervs = append(right_intervs, chooseCustomIntervals(cmd.right_intervs, i, 5))
 	}
 
 	var init_indexes []int = nil
@@ -290,13 +378,19 @@ func wordsVerificationFunction(sentence []string, sentence_index int, cmd comman
 		//log.Println("Condition index:", curr_words_cond_index)
 
 		for curr_words_cond_index, curr_words_condition := range words_list {
-			if sub_verification >= len(curr_words_condition) {
+			if sub_verification >= len(curr_words_condition.words_groups) {
 				continue
 			}
 
 			// Set initial default values. The word found in the array is just for debugging purposes so far.
-			success_detects[curr_words_cond_index] = append(success_detects[curr_words_cond_index], []interface{}{true,
-				-1, "#%$&/€£@§@£"})
+			var curr_match *conditionMatch = &success_detects[curr_words_cond_index]
+			curr_match.words_matches = append(curr_match.words_matches, wordMatch{
+				found: true,
+				index: -1,
+				word:  "#%$&/€£@§@£",
+				group: -1,
+			})
+			var curr_word_match *wordMatch = &curr_match.words_matches[sub_verification]
 
 			var init_index int = init_indexes[curr_words_cond_index]
 			var index_previous_word_found int = indexes_previous_word_found[curr_words_cond_index]
@@ -320,55 +414,40 @@ func wordsVerificationFunction(sentence []string, sentence_index int, cmd comman
 			// For each word in the current 'words_list' slice and within the words interval specified, this looks
 			// for the word in the 'sentence'. When it finds one of the words in the slice, it notes down the word and
 			// the index.
-			var search_NONE_now bool = false
 			var word_detected bool = false
-			var anything_searched bool = false
-		restart_words_check:
 			for index := init_index - left_interv; index <= (init_index + right_interv); index++ {
 				if index >= sentence_len {
 					break
 				}
-				if index < 0 || index == init_index {
+				if index < 0 || index == init_index || (index >= main_word_index && index <= sentence_index) {
 					continue
 				}
 
 				//log.Println("SDFJLH")
 				//log.Println(index)
 
-				for index_words_map, words_map := range curr_words_condition {
-					if len(words_map) == 0 {
+				for index_words_map, words_group := range curr_words_condition.words_groups {
+					if len(words_group.words) == 0 {
 						// Nothing to check here
 						continue
 					}
 
 					//log.Println("JJJJJJJJJ")
 					//log.Println(sentence[index])
-					//log.Println(words_map)
-
-					var use_words_here bool = false
-					if words_map[0][0] == ALL_SUB_VERIFS_INT {
-						use_words_here = true
-					} else {
-						for _, allowed_index := range words_map[0] {
-							if allowed_index == sub_verification {
-								use_words_here = true
+					//log.Println(words_group)
 
-								break
-							}
-						}
-					}
-					if !use_words_here {
+					if !words_group.allowedAt(sub_verification) {
 						// Words group not allowed for this position index, so go to the next one
 						continue
 					}
 
-					anything_searched = true
-
-					for _, word := range words_map[1] {
-						if (word == NONE && !search_NONE_now) || (word != NONE && search_NONE_now) {
-							// Go to the next word if the current one on the list is NONE (can't check for that in the
-							// beginning, as that's what's found before starting to search: nothing --> NONE) and if
-							// it's not supposed to be checked for special commands right now, or vice-versa.
+					for _, word := range words_group.words {
+						if word == NONE {
+							// NONE is only checked in the end, if no word was found (can't check for that in the
+							// beginning, as that's what's found before starting to search: nothing --> NONE).
+							continue
+						}
+						if isExcluded(curr_words_cond_index, word) {
 							continue
 						}
 
@@ -403,18 +482,18 @@ func wordsVerificationFunction(sentence []string, sentence_index int, cmd comman
 
 			//log.Println("2---")
 
-			if !word_detected {
-				if !search_NONE_now && anything_searched {
-					// If no word was found, search NONE was not enabled, and anything was searched (there are words in
-					// lists for search and the sentence indexes are still inside the sentence), try again now also
-					// checking for the NONE command.
-					search_NONE_now = true
-
-					goto restart_words_check
+			if !word_detected && !isExcluded(curr_words_cond_index, NONE) {
+				if index_words_map := optionalWordsMapIndex(curr_words_condition, sub_verification); index_words_map != -1 {
+					// If no word was found but there's an optional words group (with NONE) allowed for this position
+					// index, the group is "found" as not being on the sentence. The index of the word found stays the
+					// same, so the next sub-verification continues from where this one began.
+					word_found_info.index_word_found_map = index_words_map
+					word_detected = true
 				}
-
-				// Else, output a false to the success array and go to the next condition since this was is garbage now.
-				success_detects[curr_words_cond_index][sub_verification] = []interface{}{false, -1, NONE}
+			}
+			if !word_detected {
+				// Else, output a false to the success array and go to the next condition since this one is garbage now.
+				*curr_word_match = wordMatch{found: false, index: -1, word: NONE, group: -1}
 
 				goto end_condition
 			}
@@ -444,16 +523,17 @@ func wordsVerificationFunction(sentence []string, sentence_index int, cmd comman
 				}
 				for sentence_counter := lowest_index + 1; sentence_counter <= (highest_index - 1); sentence_counter++ {
 					// The last part ensures the 'sentence' word being checked is not the original one.
-					if sentence_counter >= 0 && sentence_counter < sentence_len && sentence_counter != sentence_index {
+					if sentence_counter >= 0 && sentence_counter < sentence_len &&
+						(sentence_counter < main_word_index || sentence_counter > sentence_index) {
 						for _, original_word := range original_main_words {
-							if sentence[sentence_counter] == original_word {
+							if isMainWordAt(sentence, sentence_counter, original_word) {
 								// An original word is between 2 consecutive found words --> command repetition detected
 								// and we go to the next condition ("by verifying if any original word is repeated
 								// between the previous and current found words" - the words for detection are in the
 								// various conditions. We need to check them all first in this case.)
 
 								// Set one of the word detections to false to exclude this condition.
-								success_detects[curr_words_cond_index][sub_verification] = []interface{}{false, -1, NONE}
+								*curr_word_match = wordMatch{found: false, index: -1, word: NONE, group: -1}
 
 								goto end_condition
 							}
@@ -469,8 +549,19 @@ func wordsVerificationFunction(sentence []string, sentence_index int, cmd comman
 			//log.Println("4---")
 
 			// Detection successful, so update the index of the word found.
-			success_detects[curr_words_cond_index][sub_verification][1] = word_found_info.index_word_found
-			success_detects[curr_words_cond_index][sub_verification][2] = word_found_info.word_found
+			if word_found_info.word_found != NONE {
+				// An optional words group that was not found has no index on the sentence.
+				curr_word_match.index = word_found_info.index_word_found
+				if word_found_info.index_word_found > init_index {
+					curr_word_match.distance = word_found_info.index_word_found - init_index
+					curr_word_match.window = right_interv
+				} else {
+					curr_word_match.distance = init_index - word_found_info.index_word_found
+					curr_word_match.window = left_interv
+				}
+			}
+			curr_word_match.word = word_found_info.word_found
+			curr_word_match.group = word_found_info.index_word_found_map
 
 			// If there are more sub-verifications, prepare the next one
 			if sub_verification != max_sub_verifications-1 {
@@ -490,20 +581,9 @@ func wordsVerificationFunction(sentence []string, sentence_index int, cmd comman
 					}
 				end_of_loops:
 					if idx_array_mut_excl_words != -1 {
-						for i := range words_list {
-							for ii := range words_list[i] {
-								if len(words_list[i][ii]) == 0 {
-									continue
-								}
-								for iii := 0; iii < len(words_list[i][ii][1]); iii++ {
-									jjj := words_list[i][ii][1][iii]
-									for _, word_to_exclude := range mutually_exclusive_words[idx_array_mut_excl_words] {
-										if (word_to_exclude == jjj) && (word_to_exclude != word_found_info.word_found) {
-											DelElemSLICES(&words_list[i][ii][1], iii)
-											iii--
-										}
-									}
-								}
+						for _, word_to_exclude := range mutually_exclusive_words[idx_array_mut_excl_words] {
+							if word_to_exclude != word_found_info.word_found {
+								excluded_words = append(excluded_words, word_to_exclude)
 							}
 						}
 					}
@@ -527,25 +607,8 @@ func wordsVerificationFunction(sentence []string, sentence_index int, cmd comman
 						}
 					}
 					if exclude_word_found_now && (word_found_info.index_word_found_map != -1) {
-						var words_to_exclude []interface{} = nil
-						// Full copy of the slice here (I'll be deleting from the original)
-						CopyFullSLICES(&words_to_exclude, curr_words_condition[word_found_info.index_word_found_map][1])
-
-						for ii := range curr_words_condition {
-							if len(curr_words_condition[ii]) == 0 {
-								continue
-							}
-							var word_map *[]interface{} = &curr_words_condition[ii][1]
-							for iii := 0; iii < len(*word_map); iii++ {
-								jjj := (*word_map)[iii]
-								for _, word_to_exclude := range words_to_exclude {
-									if word_to_exclude == jjj {
-										DelElemSLICES(word_map, iii)
-										iii--
-									}
-								}
-							}
-						}
+						conds_excluded_words[curr_words_cond_index] = append(conds_excluded_words[curr_words_cond_index],
+							curr_words_condition.words_groups[word_found_info.index_word_found_map].words...)
 					}
 				}
 
@@ -614,106 +677,140 @@ for the command 'main_words'.
 
 – Params:
   - results_wordsVerifFunc – the direct return from wordsVerificationFunction()
-  - sentence_word – the current 'sentence_word' on the sentenceCmdsDetector()
-  - cmds_GL_index – the current 'cmds_GL' looping index on the sentenceCmdsDetector()
+  - main_word – the main word that triggered the detection on the sentenceCmdsDetector() (the whole phrase, for
+    phrases - like "good_night")
+  - cmd – the command being checked on the sentenceCmdsDetector()
 
 – Returns:
 
-– the index of the final accepted 'words_list' condition for the current 'sentence_word'
+– the index of the final accepted 'words_list' condition for the current 'main_word', or -1 if none was accepted
 */
-func checkMainWordsRetConds(results_wordsVerifFunc [][][]interface{}, sentence_word string, cmds_GL_index int) int {
-	var final_condition int = -1
+func checkMainWordsRetConds(results_wordsVerifFunc []conditionMatch, main_word string, cmd commandInfo) int {
+	var final_conditions []int = bestMainWordsRetConds(results_wordsVerifFunc, main_word, cmd)
+	if len(final_conditions) == 0 {
+		return -1
+	}
+
+	// If more than one are tied, the first of them on the 'words_list' is picked.
+	return final_conditions[0]
+}
+
+/*
+bestMainWordsRetConds is the same as checkMainWordsRetConds(), but returns all the conditions tied as the final one (the
+accepted ones with the most words found) - to report ambiguities.
+
+-----------------------------------------------------------
+
+– Params:
+  - same as in checkMainWordsRetConds()
+
+– Returns:
+  - the indexes of the tied 'words_list' conditions, in order, or nil if none was accepted
+*/
+func bestMainWordsRetConds(results_wordsVerifFunc []conditionMatch, main_word string, cmd commandInfo) []int {
+	var final_conditions []int = nil
 	// Must be the biggest condition because, for example "reboot phone" and "reboot phone into
 	// recovery", and the sentence is "reboot phone into recovery". Both are successful
 	// detections (all words are found in both variations). But only the 2nd (the *biggest*) is
 	// correct, because more words were found, and more words has higher priority than fewer
-	// words.
+	// words. Optional words groups that were not found don't count (or "record a frontal video" would be detected as
+	// "record a [rear] video" too).
 	var biggest_len int = -1
 
 	//log.Println(success_detects)
-	for ii, jj := range results_wordsVerifFunc {
-		var all_true bool = true
-		for _, jjj := range jj {
-			all_true = all_true && jjj[0].(bool)
-		}
-		if all_true {
-			var main_words_ret_conds [][]string = cmds_GL[cmds_GL_index].main_words_ret_conds
-			var arr_id int = 0
-			if ii >= len(main_words_ret_conds) {
-				// In case there are not enough return conditions, use the last one present.
-				arr_id = len(main_words_ret_conds) - 1
-			} else {
-				arr_id = ii
+	for ii, condition_match := range results_wordsVerifFunc {
+		var words_found int = condition_match.wordsFound()
+		if condition_match.allFound() && mainWordAccepted(ii, main_word, cmd) {
+			//log.Println("QQQQQQQQQQQQQQQQQQ")
+			if words_found > biggest_len {
+				final_conditions = []int{ii}
+				biggest_len = words_found
+			} else if words_found == biggest_len {
+				final_conditions = append(final_conditions, ii)
 			}
+		}
+	}
 
-			var any_main_word bool = false
-			if (len(main_words_ret_conds[arr_id]) == 1) &&
-				(main_words_ret_conds[arr_id][0] == ANY_MAIN_WORD) {
-				any_main_word = true
-			}
-			var words_exclude_anyway []string = nil
-			//log.Println("SSSSSSSSSSSSSSS")
-			//log.Println(main_words_ret_conds[arr_id])
-			for _, word := range main_words_ret_conds[arr_id] {
-				if word == ANY_MAIN_WORD {
-					any_main_word = true
-				} else if strings.HasPrefix(word, "-") {
-					words_exclude_anyway = append(words_exclude_anyway, regexp.MustCompile("[^a-zA-Z]+").ReplaceAllString(word, ""))
-				}
-			}
+	return final_conditions
+}
 
-			//log.Println("DDDDDDDDDDDDDDDDDDD")
-			//log.Println(any_main_word)
-			//log.Println(words_exclude_anyway)
+/*
+mainWordAccepted checks if the return conditions for the command 'main_words' accept a main word for a condition.
 
-			for _, word := range main_words_ret_conds[arr_id] {
-				if strings.HasPrefix(word, "-") {
-					// Don't do anything if it's a word that beings with a "-", which means it's to exclude it from the
-					// accepted main words. The actual verification will be on the ANY_MAIN_WORD command or any other
-					// main words on the list. The ones beginning with "-" are only added to an exclusion list to be
-					// iterated in the end of each iteration of this loop.
-					continue
-				}
+-----------------------------------------------------------
 
-				//log.Println("++++++++++++++")
-				//log.Println(word)
-				//log.Println(sentence_word)
+– Params:
+  - condition – the index of the condition on the 'words_list'
+  - main_word – same as in checkMainWordsRetConds()
+  - cmd – same as in checkMainWordsRetConds()
 
-				var actual_word string = word
-				if !isSpecialCommand(actual_word) {
-					// If it's a special command like ;4;, keep it like that. Else, leave only alphanumeric characters.
-					// Example, "-fast", to remove the word "fast" from the accepted main words.
-					regexp.MustCompile("[^a-zA-Z0-9 ]+").ReplaceAllString(word, "")
-				}
+– Returns:
+  - true if the main word is accepted for the condition, false otherwise
+*/
+func mainWordAccepted(condition int, main_word string, cmd commandInfo) bool {
+	var main_words_ret_conds [][]string = cmd.main_words_ret_conds
+	var arr_id int = 0
+	if condition >= len(main_words_ret_conds) {
+		// In case there are not enough return conditions, use the last one present.
+		arr_id = len(main_words_ret_conds) - 1
+	} else {
+		arr_id = condition
+	}
 
-				// If any main word counts, then if the current word matches the sentence word or not doesn't matter,
-				// because the command was triggered by a main word, and any main word is accepted.
-				if any_main_word || actual_word == sentence_word {
-					// Though, there can still be words that must be excluded ("All except these: [...]").
-					var exclude_word bool = false
-					for _, word_exclude := range words_exclude_anyway {
-						if sentence_word == word_exclude {
-							exclude_word = true
+	var any_main_word bool = false
+	if (len(main_words_ret_conds[arr_id]) == 1) &&
+		(main_words_ret_conds[arr_id][0] == ANY_MAIN_WORD) {
+		any_main_word = true
+	}
+	var words_exclude_anyway []string = nil
+	//log.Println("SSSSSSSSSSSSSSS")
+	//log.Println(main_words_ret_conds[arr_id])
+	for _, word := range main_words_ret_conds[arr_id] {
+		if word == ANY_MAIN_WORD {
+			any_main_word = true
+		} else if strings.HasPrefix(word, "-") {
+			words_exclude_anyway = append(words_exclude_anyway, strings.TrimPrefix(word, "-"))
+		}
+	}
 
-							break
-						}
-					}
-					//log.Println("FFFFFFFFFFFFFFFFF")
-					//log.Println(actual_word)
-					//log.Println(sentence_word)
-					//log.Println(exclude_word)
-					// If the 'sentence_word' is not on the excluded list, carry on.
-					if !exclude_word && len(jj) > biggest_len {
-						//log.Println("QQQQQQQQQQQQQQQQQQ")
-						final_condition = ii
-						biggest_len = len(jj)
-
-						break
-					}
+	//log.Println("DDDDDDDDDDDDDDDDDDD")
+	//log.Println(any_main_word)
+	//log.Println(words_exclude_anyway)
+
+	for _, word := range main_words_ret_conds[arr_id] {
+		if strings.HasPrefix(word, "-") {
+			// Don't do anything if it's a word that beings with a "-", which means it's to exclude it from the
+			// accepted main words. The actual verification will be on the ANY_MAIN_WORD command or any other
+			// main words on the list. The ones beginning with "-" are only added to an exclusion list to be
+			// iterated in the end of each iteration of this loop.
+			continue
+		}
+
+		//log.Println("++++++++++++++")
+		//log.Println(word)
+		//log.Println(main_word)
+
+		// The words beginning with "-" were skipped above, so this is either a special command like ;4; or a main
+		// word, and both are used as they are (the main words were validated when the command was loaded).
+		var actual_word string = word
+
+		// If any main word counts, then if the current word matches the sentence word or not doesn't matter,
+		// because the command was triggered by a main word, and any main word is accepted.
+		if any_main_word || actual_word == main_word {
+			// Though, there can still be words that must be excluded ("All except these: [...]").
+			for _, word_exclude := range words_exclude_anyway {
+				if main_word == word_exclude {
+					return false
 				}
 			}
+			//log.Println("FFFFFFFFFFFFFFFFF")
+			//log.Println(actual_word)
+			//log.Println(main_word)
+
+			// If the 'main_word' is not on the excluded list, carry on.
+			return true
 		}
 	}
 
-	return final_condition
+	return false
 }
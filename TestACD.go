@@ -22,7 +22,12 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"log"
+	"math/rand"
+	"strconv"
 	"strings"
 
 	"ACD/ACD"
@@ -62,6 +67,1472 @@ func testCommandsDetection() {
 	}
 }
 
+func testCmdsEncoding() {
+	log.Println("Running commands encoding tests...")
+
+	// Big command IDs used to lose the variation digits when the commands were encoded as float32s.
+	var tests = [...]struct {
+		detection ACD.Detection
+		encoded   string
+	}{
+		{ACD.Detection{Cmd_id: 4, Sub_cmd_index: 0}, "4.00001"},
+		{ACD.Detection{Cmd_id: 10, Sub_cmd_index: 229}, "10.0023"},
+		{ACD.Detection{Cmd_id: 123456, Sub_cmd_index: 41}, "123456.00042"},
+		{ACD.Detection{Cmd_id: 100000000, Sub_cmd_index: 99998}, "100000000.99999"},
+		{ACD.Detection{Cmd_id: 0, Sub_cmd_index: -1, Warning: ACD.WARNING_WHATS_IT}, ACD.WARN_WHATS_IT},
+	}
+
+	var successes int = 0
+	for _, j := range tests {
+		var encoded string = j.detection.String()
+		if encoded != j.encoded {
+			log.Println("PROBLEM DETECTED: encoded", j.detection, "as", encoded, "instead of", j.encoded)
+		} else if j.detection.Warning == ACD.WARNING_NONE && ACD.GetSubCmdIndex(encoded) != j.detection.Sub_cmd_index {
+			log.Println("PROBLEM DETECTED: decoded", encoded, "as", ACD.GetSubCmdIndex(encoded))
+		} else {
+			successes++
+		}
+	}
+
+	// Strings that are not returned commands are not decoded (and don't panic).
+	var bad_encoded = []string{"4.000001", "4", "4.", "4.0000a", "4.-0001", "4.00000", ACD.WARN_WHATS_IT}
+	for _, encoded := range bad_encoded {
+		if sub_cmd_index := ACD.GetSubCmdIndex(encoded); sub_cmd_index != -1 {
+			log.Println("PROBLEM DETECTED: decoded", encoded, "as", sub_cmd_index)
+		} else {
+			successes++
+		}
+	}
+	log.Println("Results (successes/total):", successes, "/", len(tests)+len(bad_encoded))
+}
+
+func testMultipleDetectors() {
+	log.Println("Running multiple detectors tests...")
+
+	// Each detector must only know its own commands, even with the same IDs on both.
+	var detector_1 *ACD.Detector = ACD.NewDetector()
+	detector_1.ReloadCmdsArray("1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi")
+	var detector_2 *ACD.Detector = ACD.NewDetector()
+	detector_2.ReloadCmdsArray("1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||bluetooth")
+
+	var tests = [...]struct {
+		detector *ACD.Detector
+		sentence string
+		expected string
+	}{
+		{detector_1, "turn on the wifi", "1.00001"},
+		{detector_1, "turn on the bluetooth", ""},
+		{detector_2, "turn on the wifi", ""},
+		{detector_2, "turn off the bluetooth", "1.00002"},
+	}
+
+	var successes int = 0
+	for _, j := range tests {
+		var output string = j.detector.MainInternal(j.sentence, false, true, "|")
+		var detected_commands string = strings.Split(output, ACD.INFO_CMDS_SEPARATOR)[1]
+		if detected_commands != j.expected {
+			log.Println("PROBLEM DETECTED: " + j.sentence + " / " + j.expected + " -----> " + output)
+		} else {
+			successes++
+		}
+	}
+	log.Println("Results (successes/total):", successes, "/", len(tests))
+}
+
+func testCmdsDefinitions(commands_str string) {
+	log.Println("Running commands definitions tests...")
+
+	var successes int = 0
+	var total int = 0
+	var check = func(ok bool, problem ...any) {
+		total++
+		if ok {
+			successes++
+		} else {
+			log.Println(append([]any{"PROBLEM DETECTED:"}, problem...)...)
+		}
+	}
+
+	// Exporting and loading back must give the same commands, in both formats.
+	var detector_str *ACD.Detector = ACD.NewDetector()
+	detector_str.ReloadCmdsArray(commands_str)
+	for _, format := range []string{ACD.CMDS_FORMAT_JSON, ACD.CMDS_FORMAT_YAML} {
+		var exported bytes.Buffer
+		var err error = detector_str.ExportCmds(&exported, format)
+		check(err == nil, "exporting to", format, "-->", err)
+
+		var detector_defs *ACD.Detector = ACD.NewDetector()
+		err = detector_defs.LoadCmdsFromReader(bytes.NewReader(exported.Bytes()), format)
+		check(err == nil, "loading the exported", format, "-->", err)
+
+		var exported_again bytes.Buffer
+		_ = detector_defs.ExportCmds(&exported_again, format)
+		check(exported.String() == exported_again.String(), "the", format, "export changed after loading it back")
+
+		const SENTENCE string = "fast reboot the phone"
+		check(detector_defs.MainInternal(SENTENCE, false, true, "|") == detector_str.MainInternal(SENTENCE, false, true,
+			"|"), "different detections after loading the exported", format)
+	}
+
+	// All the parameters must be accepted, and comments too.
+	const DEFINITIONS_YAML string = `
+commands:
+  # Toggle the Wi-Fi, with the word searches a bit wider than normal
+  - id: 4
+    types: ["1"]
+    words_list: [wifi]
+    left_intervs: {-1: 1}
+    right_intervs: {-1: 6}
+    init_indexes_sub_verifs: {-1: ";3;"}
+    exclude_word_found_group: []
+    ignore_repets_cmds: true
+    exclude_main_words: true
+`
+	var detector_yaml *ACD.Detector = ACD.NewDetector()
+	var err error = detector_yaml.LoadCmdsFromReader(strings.NewReader(DEFINITIONS_YAML), ACD.CMDS_FORMAT_YAML)
+	check(err == nil, "loading the YAML definitions -->", err)
+
+	// An empty exclude_word_found_group (disabled) must stay empty when exported and loaded back, and not go back to
+	// the default (not given).
+	for _, format := range []string{ACD.CMDS_FORMAT_JSON, ACD.CMDS_FORMAT_YAML} {
+		var exported bytes.Buffer
+		_ = detector_yaml.ExportCmds(&exported, format)
+		check(strings.Contains(exported.String(), "exclude_word_found_group"), "the empty exclude_word_found_group "+
+			"was not exported to", format)
+
+		var detector_defs *ACD.Detector = ACD.NewDetector()
+		err = detector_defs.LoadCmdsFromReader(bytes.NewReader(exported.Bytes()), format)
+		check(err == nil, "loading the exported", format, "-->", err)
+
+		var exported_again bytes.Buffer
+		_ = detector_defs.ExportCmds(&exported_again, format)
+		check(exported.String() == exported_again.String(), "the", format, "export with an empty "+
+			"exclude_word_found_group changed after loading it back")
+	}
+
+	// And wrong ones must be reported.
+	err = detector_yaml.LoadCmdsFromReader(strings.NewReader(`{"commands": [{"id": 4, "types": ["1"], `+
+		`"words_list": ["wifi"], "left_intervs": {"first": 1}}]}`), ACD.CMDS_FORMAT_JSON)
+	check(err != nil, "invalid sub-verification number accepted")
+
+	log.Println("Results (successes/total):", successes, "/", total)
+}
+
+func testCmdsValidation(commands_str string) {
+	log.Println("Running commands validation tests...")
+
+	var successes int = 0
+	var total int = 0
+	var check = func(ok bool, problem ...any) {
+		total++
+		if ok {
+			successes++
+		} else {
+			log.Println(append([]any{"PROBLEM DETECTED:"}, problem...)...)
+		}
+	}
+
+	var detector *ACD.Detector = ACD.NewDetector()
+	var err error = detector.ReloadCmdsArray(commands_str)
+	check(err == nil, "the test commands were not accepted -->", err)
+
+	// The command on the index 0 of the list must be possible to update and remove too.
+	_ = detector.ReloadCmdsArray("1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi")
+	err = detector.AddUpdateCmd("1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||bluetooth")
+	check(err == nil && detector.MainInternal("turn on the bluetooth", false, true, "|") ==
+		"bluetooth|turn on the|\\\\//1.00001", "the first command was not updated")
+	detector.RemoveCmd(1)
+	check(detectedCmds(detector, "turn on the bluetooth") == "", "the first command was not removed")
+
+	var tests = []struct {
+		command_info_str string
+		exp_fields       []string
+	}{
+		{"1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi", nil},
+		{"1.5||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi", []string{"id"}},
+		{"0||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi", []string{"id"}},
+		{"1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||wifi", []string{"command string"}},
+		{"1||42||||||wifi", []string{"types[0]", "main_words"}},
+		{"1||x||turn||||wifi", []string{"types[0]"}},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||||||wifi", []string{"main_words"}},
+		{"1||" + ACD.CMDi_TYPE_REBOOT + "||fast||fast|;9;||phone", []string{"main_words_ret_conds[1]"}},
+		{"1||" + ACD.CMDi_TYPE_REBOOT + "||fast||;4; -slow||phone", []string{"main_words_ret_conds[0]"}},
+		{"1||" + ACD.CMDi_TYPE_REBOOT + "||fast||-fast||phone", []string{"main_words_ret_conds[0]"}},
+		{"1||" + ACD.CMDi_TYPE_REBOOT + "||fast||slow||phone", []string{"main_words_ret_conds[0]"}},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||frontal  picture", []string{"words_list[0]"}},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||picture/|;5;", []string{"words_list[0]", "words_list[1]"}},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||picture ;0;", []string{"words_list[0]"}},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||picture|frontal picture", nil},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||picture|the/;0; picture", nil},
+		{"1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi|bluetooth", nil},
+		{"1||turn_onff+shut_down||||||wifi", nil},
+		{"1||open_close||||||door", []string{"types[0]", "main_words"}},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||[rear/back]@0,1 picture/# #@2", nil},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||[rear picture", []string{"words_list[0]"}},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||rear] picture", []string{"words_list[0]"}},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||[] picture", []string{"words_list[0]"}},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||picture@first|picture@-1|picture@", []string{"words_list[0]",
+			"words_list[1]", "words_list[2]"}},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||## picture", []string{"words_list[0]"}},
+		// As many conditions as the returned commands can have ("1.99999"), and one more (the same as "1.00001")
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||" + strings.Repeat("picture|", ACD.MAX_SUB_CMDS-2) + "picture", nil},
+		{"1||" + ACD.CMDi_TYPE_NONE + "||take||||" + strings.Repeat("picture|", ACD.MAX_SUB_CMDS-1) + "picture",
+			[]string{"words_list"}},
+		// The same, but with the type doubling the conditions (on and off)
+		{"1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||" + strings.Repeat("wifi|", ACD.MAX_SUB_CMDS/2-1) + "wifi",
+			[]string{"words_list"}},
+	}
+	for _, test := range tests {
+		var detector_test *ACD.Detector = ACD.NewDetector()
+		err = detector_test.AddUpdateCmd(test.command_info_str)
+
+		var fields []string = nil
+		for _, cmd_err := range cmdDefinitionErrors(err) {
+			fields = append(fields, cmd_err.Field)
+		}
+		check(strings.Join(fields, ", ") == strings.Join(test.exp_fields, ", "), test.command_info_str, "-->",
+			err, "(expected problems on:", test.exp_fields, ")")
+	}
+
+	// A list with invalid commands must not be loaded at all, and all the problems must be reported.
+	_ = detector.ReloadCmdsArray("1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi")
+	err = detector.ReloadCmdsArray("1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||bluetooth\\2||42||||||x\\3||x||||||y")
+	check(len(cmdDefinitionErrors(err)) == 4 && detector.MainInternal("turn on the wifi", false, true, "|") ==
+		"wifi|turn on the|\\\\//1.00001", "the invalid commands list was loaded or not fully reported -->", err)
+
+	// The same for the definitions files, which can also repeat IDs.
+	err = detector.LoadCmdsFromReader(strings.NewReader(`{"commands": [{"id": 4, "types": ["1"], "words_list": `+
+		`["wifi"]}, {"id": 4, "types": ["1"], "words_list": ["bluetooth"]}]}`), ACD.CMDS_FORMAT_JSON)
+	var cmd_errs []*ACD.CmdDefinitionError = cmdDefinitionErrors(err)
+	check(len(cmd_errs) == 1 && cmd_errs[0].Cmd_id == 4 && cmd_errs[0].Field == "id", "repeated ID not reported -->",
+		err)
+
+	// And for the string format too (the 2nd command would replace the 1st one).
+	_ = detector.ReloadCmdsArray("1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi")
+	err = detector.ReloadCmdsArray("1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi\\1||" + ACD.CMDi_TYPE_TURN_ONFF +
+		"||||||bluetooth")
+	cmd_errs = cmdDefinitionErrors(err)
+	check(len(cmd_errs) == 1 && cmd_errs[0].Cmd_id == 1 && cmd_errs[0].Field == "id" &&
+		strings.Contains(err.Error(), "command 1,"), "repeated ID not reported on the string format -->", err)
+	check(detectedCmds(detector, "turn on the wifi") == "1.00001", "the commands with a repeated ID were loaded")
+
+	// A words group that only has main words can never be detected if the main words are excluded from the conditions.
+	err = detector.LoadCmdsFromReader(strings.NewReader(`{"commands": [{"id": 5, "types": ["0"], "main_words": `+
+		`["take", "shoot"], "words_list": ["picture", "take/shoot picture", "take/;0; video"], "exclude_main_words": `+
+		`true}]}`), ACD.CMDS_FORMAT_JSON)
+	cmd_errs = cmdDefinitionErrors(err)
+	check(len(cmd_errs) == 1 && cmd_errs[0].Cmd_id == 5 && cmd_errs[0].Field == "words_list[1]",
+		"group emptied by exclude_main_words not reported -->", err)
+
+	log.Println("Results (successes/total):", successes, "/", total)
+}
+
+func testConditionsOrder() {
+	log.Println("Running conditions order tests...")
+
+	var detector *ACD.Detector = ACD.NewDetector()
+	_ = detector.ReloadCmdsArray("1||" + ACD.CMDi_TYPE_NONE + "||take||||picture|frontal picture|frontal picture " +
+		"please\\2||" + ACD.CMDi_TYPE_REBOOT + "||fast||fast|;4; -fast||reboot/restart device|device|device recovery\\3||" +
+		ACD.CMDi_TYPE_START + "||record||record|;4; -record||audio|recording audio")
+
+	// The conditions are reordered internally (bigger ones first), but the variants must still be the ones written.
+	var tests = []struct {
+		sentence string
+		exp_cmds string
+	}{
+		{"take a picture", "1.00001"},
+		{"take a frontal picture", "1.00002"},
+		{"take a frontal picture please", "1.00003"},
+		{"fast reboot the device", "2.00001"},
+		{"reboot the device", "2.00002"},
+		{"reboot the device into recovery", "2.00003"},
+		{"record audio", "3.00001"},
+		{"start recording audio", "3.00002"},
+	}
+
+	var successes int = 0
+	for _, test := range tests {
+		var cmds string = detectedCmds(detector, test.sentence)
+		if cmds == test.exp_cmds {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", test.sentence, "/", test.exp_cmds, "----->", cmds)
+		}
+	}
+
+	log.Println("Results (successes/total):", successes, "/", len(tests))
+}
+
+func testCompactSyntax() {
+	log.Println("Running compact syntax tests...")
+
+	var detector *ACD.Detector = ACD.NewDetector()
+	var err error = detector.ReloadCmdsArray("1||" + ACD.CMDi_TYPE_NONE + "||record||||[rear] video|frontal video|" +
+		"audio\\2||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi@0\\3||" + ACD.CMDi_TYPE_NONE + "||set||||alarm for # " +
+		"minutes\\4||" + ACD.CMDi_TYPE_NONE + "||take||||[a/the]@0 picture@1")
+	if err != nil {
+		log.Println("PROBLEM DETECTED: the compact syntax commands were not accepted -->", err)
+	}
+
+	var tests = []struct {
+		sentence string
+		exp_cmds string
+	}{
+		// Optional words groups
+		{"record a video", "1.00001"},
+		{"record a rear video", "1.00001"},
+		{"record a frontal video", "1.00002"},
+		{"record audio", "1.00003"},
+		// Position indexes
+		{"turn the wifi on", "2.00001"},
+		{"turn the wifi off", "2.00002"},
+		{"turn on the wifi", ""},
+		// Digits
+		{"set an alarm for 5 minutes", "3.00001"},
+		{"set an alarm for some minutes", ""},
+		// All mixed
+		{"take picture", "4.00001"},
+		{"take the picture", "4.00001"},
+		{"take picture the", ""},
+	}
+
+	var successes int = 0
+	for _, test := range tests {
+		var cmds string = detectedCmds(detector, test.sentence)
+		if cmds == test.exp_cmds {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", test.sentence, "/", test.exp_cmds, "----->", cmds)
+		}
+	}
+
+	log.Println("Results (successes/total):", successes, "/", len(tests))
+}
+
+func testSearchWindows() {
+	log.Println("Running search windows tests...")
+
+	var tests = []struct {
+		intervs  string
+		sentence string
+		exp_cmds string
+	}{
+		// Default windows: 0 to the left and 5 to the right
+		{``, "turn on please the really old wifi", "4.00001"},
+		{``, "turn on please the really very old wifi", ""},
+		{``, "wifi please turn on", ""},
+		// Smaller windows must make the detection fail too (the right one was being ignored)
+		{`"right_intervs": {"all": 2}`, "turn on please the really old wifi", ""},
+		{`"right_intervs": {"all": 2}`, "turn on the wifi", "4.00001"},
+		// Per sub-verification
+		{`"right_intervs": {"1": 8}`, "turn on please the really very old wifi", "4.00001"},
+		{`"right_intervs": {"1": 8}`, "turn the really very very old wifi on", ""},
+		{`"right_intervs": {"0": 8}`, "turn the really very very old wifi on", "4.00001"},
+		// Even and odd sub-verifications, with the specific ones over them and them over all
+		{`"right_intervs": {"odd": 8}`, "turn on please the really very old wifi", "4.00001"},
+		{`"right_intervs": {"even": 8}`, "turn on please the really very old wifi", ""},
+		{`"right_intervs": {"even": 8}`, "turn the really very very old wifi on", "4.00001"},
+		{`"right_intervs": {"-2": 8}`, "turn the really very very old wifi on", "4.00001"},
+		{`"right_intervs": {"odd": 8, "1": 2}`, "turn on please the really very old wifi", ""},
+		{`"right_intervs": {"all": 2, "odd": 8}`, "turn on please the really very old wifi", "4.00001"},
+		// Left windows
+		{`"left_intervs": {"all": 3}`, "wifi please turn on", "4.00001"},
+		{`"left_intervs": {"0": 3}`, "wifi please turn on", "4.00001"},
+		{`"left_intervs": {"1": 3}`, "wifi please turn on", "4.00001"},
+	}
+
+	var successes int = 0
+	for _, test := range tests {
+		var definitions string = `{"commands": [{"id": 4, "types": ["1"], "words_list": ["wifi"]` +
+			strings.TrimSuffix(", "+test.intervs, ", ") + `}]}`
+		var detector *ACD.Detector = ACD.NewDetector()
+		if err := detector.LoadCmdsFromReader(strings.NewReader(definitions), ACD.CMDS_FORMAT_JSON); err != nil {
+			log.Println("PROBLEM DETECTED:", definitions, "not accepted -->", err)
+
+			continue
+		}
+
+		var cmds string = detectedCmds(detector, test.sentence)
+		if cmds == test.exp_cmds {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", test.intervs, "/", test.sentence, "/", test.exp_cmds, "----->", cmds)
+		}
+	}
+
+	log.Println("Results (successes/total):", successes, "/", len(tests))
+}
+
+func testCmdTypes() {
+	log.Println("Running command types tests...")
+
+	var successes int = 0
+	var total int = 0
+	var check = func(ok bool, problem ...any) {
+		total++
+		if ok {
+			successes++
+		} else {
+			log.Println(append([]any{"PROBLEM DETECTED:"}, problem...)...)
+		}
+	}
+
+	var detector *ACD.Detector = ACD.NewDetector()
+	var err error = errors.Join(
+		detector.RegisterCmdType(ACD.CmdType{
+			Name:          "open_close",
+			Trigger_words: []string{"open", "close"},
+			Expansion:     ACD.CMD_TYPE_EXPAND_TRIGGERS,
+		}),
+		detector.RegisterCmdTypeStr("lock_unlock||lock unlock||[the]||triggers"),
+		detector.RegisterCmdType(ACD.CmdType{
+			Name:             "increase_decrease",
+			Trigger_words:    []string{"turn"},
+			Follow_up_groups: []string{"up/down"},
+			Expansion:        ACD.CMD_TYPE_EXPAND_VARIANTS,
+		}),
+	)
+	check(err == nil, "the command types were not registered -->", err)
+
+	// The types can be referred to by their names, and the built-in ones also by their constants.
+	err = detector.ReloadCmdsArray("1||open_close||||||door/doors|window/windows\\2||lock_unlock||||||car\\" +
+		"3||increase_decrease||||||volume|brightness\\4||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi|bluetooth\\" +
+		"5||shut_down||||||device/phone\\6||turn_onff+increase_decrease||||||lights")
+	check(err == nil, "the commands with the types were not loaded -->", err)
+
+	var tests = []struct {
+		sentence string
+		exp_cmds string
+	}{
+		// One variant for each trigger word
+		{"open the door", "1.00001"},
+		{"open the windows", "1.00002"},
+		{"close the door", "1.00003"},
+		{"close the window", "1.00004"},
+		{"lock the car", "2.00001"},
+		{"unlock car", "2.00002"},
+		// One variant for each follow-up word
+		{"turn the volume up", "3.00001"},
+		{"turn up the brightness", "3.00002"},
+		{"turn down the volume", "3.00003"},
+		{"turn the brightness down", "3.00004"},
+		// Built-in types
+		{"turn on the bluetooth", "4.00002"},
+		{"turn off the wifi", "4.00003"},
+		{"shut down the phone", "5.00001"},
+		{"power off the device", "5.00001"},
+		// Combined types
+		{"turn the lights on up", "6.00001"},
+		{"turn off the lights down", "6.00004"},
+	}
+	for _, test := range tests {
+		var cmds string = detectedCmds(detector, test.sentence)
+		check(cmds == test.exp_cmds, test.sentence, "/", test.exp_cmds, "----->", cmds)
+	}
+
+	// Each detector has its own types.
+	err = ACD.NewDetector().AddUpdateCmd("1||open_close||||||door")
+	check(len(cmdDefinitionErrors(err)) > 0, "type registered on a detector used on another one")
+
+	// Invalid types must not be registered.
+	for _, cmd_type_str := range []string{
+		"turn_onff||turn||on/off||variants",
+		"7||seven||||groups",
+		"Open Close||open close||||triggers",
+		"no_triggers||||on/off||variants",
+		"no_follow_ups||turn||||variants",
+		"bad_group||turn||on/||variants",
+		"bad_expansion||turn||on/off||everything",
+		"missing_fields||turn||on/off",
+	} {
+		check(detector.RegisterCmdTypeStr(cmd_type_str) != nil, "invalid command type accepted:", cmd_type_str)
+	}
+
+	log.Println("Results (successes/total):", successes, "/", total)
+}
+
+func testMainWordsPhrases() {
+	log.Println("Running main words phrases tests...")
+
+	var successes int = 0
+	var total int = 0
+	var check = func(ok bool, problem ...any) {
+		total++
+		if ok {
+			successes++
+		} else {
+			log.Println(append([]any{"PROBLEM DETECTED:"}, problem...)...)
+		}
+	}
+
+	var detector *ACD.Detector = ACD.NewDetector()
+	var err error = detector.RegisterCmdType(ACD.CmdType{
+		Name:          "what_is",
+		Trigger_words: []string{"what's", "what_is"},
+		Expansion:     ACD.CMD_TYPE_EXPAND_GROUPS,
+	})
+	check(err == nil, "the command type was not registered -->", err)
+
+	err = detector.ReloadCmdsArray("1||0||good_night good_morning||good_night|good_morning||everyone/all\\" +
+		"2||0||good||||night/morning\\3||what_is||||||weather\\4||0||turn_down||||volume|brightness")
+	check(err == nil, "the commands with phrases were not loaded -->", err)
+
+	var tests = []struct {
+		sentence string
+		exp_cmds string
+	}{
+		// The return conditions use the whole phrase. The phrase is checked before the shorter "good", and all its words
+		// are invalidated with the detection (or "good" would detect the command 2 with the "night" too).
+		{"good night everyone", "1.00001"},
+		{"good morning to all", "1.00002"},
+		// Without a detection with the phrase, the shorter main word is used
+		{"good night", "2.00001"},
+		// The words of the phrase must be together
+		{"good the night everyone", "2.00001"},
+		// Phrases as trigger words of types - corrected as the sentence ("what_is" is "what's")
+		{"what is the weather", "3.00001"},
+		{"what's the weather", "3.00001"},
+		{"what the weather", ""},
+		// The words of the phrase are never the words found
+		{"turn down the brightness", "4.00002"},
+		{"turn the volume down", ""},
+	}
+	for _, test := range tests {
+		var cmds string = detectedCmds(detector, test.sentence)
+		check(cmds == test.exp_cmds, test.sentence, "/", test.exp_cmds, "----->", cmds)
+	}
+
+	// Phrases with empty words are not accepted.
+	for _, command_str := range []string{"5||0||good__night||||everyone", "5||0||_night||||everyone",
+		"5||0||good_||||everyone"} {
+		var errs []*ACD.CmdDefinitionError = cmdDefinitionErrors(detector.AddUpdateCmd(command_str))
+		check(len(errs) == 1 && errs[0].Field == "main_words[0]", "invalid phrase accepted:", command_str)
+	}
+	check(detector.RegisterCmdTypeStr("bad_phrase||what__is||||groups") != nil, "invalid trigger phrase accepted")
+
+	log.Println("Results (successes/total):", successes, "/", total)
+}
+
+func testOptimalSegmentation() {
+	log.Println("Running optimal segmentation tests...")
+
+	var detector *ACD.Detector = ACD.NewDetector()
+	if err := detector.ReloadCmdsArray("1||0||turn||||on\\2||0||turn||||on wifi\\3||0||good||||night\\" +
+		"4||0||night||||mode on/off"); err != nil {
+		log.Println("PROBLEM DETECTED: the commands were not loaded -->", err)
+
+		return
+	}
+
+	var tests = []struct {
+		detector    *ACD.Detector
+		sentence    string
+		exp_greedy  string
+		exp_optimal string
+	}{
+		// The first command checked takes the "on" that the second one needs
+		{detector, "turn on the wifi", "1.00001", "2.00001"},
+		{detector, "turn on", "1.00001", "1.00001"},
+		// The command on the left takes the "night" that the one on the right needs
+		{detector, "good night mode on", "3.00001", "4.00001"},
+		{detector, "good night", "3.00001", "3.00001"},
+		// Non-overlapping commands are all kept, in the order of the sentence
+		{detector, "turn on the wifi good night", "1.00001, 3.00001", "2.00001, 3.00001"},
+		// The shipped commands (and the special commands)
+		{nil, "fast reboot the phone", "14.00001", "14.00001"},
+		{nil, "turn on wifi and the bluetooth", "4.00001, 6.00001", "4.00001, 6.00001"},
+		{nil, "turn on the wifi. no, don't turn on the wifi", "4.00001", "4.00001"},
+	}
+
+	var successes int = 0
+	for _, test := range tests {
+		var detect = func(optimal_segmentation bool) string {
+			var options ACD.DetectOptions = ACD.DetectOptions{
+				Invalidate_detec_words: true,
+				Optimal_segmentation:   optimal_segmentation,
+			}
+			var result ACD.DetectionResult
+			var err error
+			if test.detector != nil {
+				result, err = test.detector.Detect(test.sentence, options)
+			} else {
+				result, err = ACD.Detect(test.sentence, options)
+			}
+			if err != nil {
+				return err.Error()
+			}
+
+			var cmds []string = nil
+			for _, detection := range result.Detections {
+				cmds = append(cmds, detection.String())
+			}
+
+			return strings.Join(cmds, ACD.CMDS_SEPARATOR)
+		}
+
+		var cmds_greedy string = detect(false)
+		var cmds_optimal string = detect(true)
+		if cmds_greedy == test.exp_greedy && cmds_optimal == test.exp_optimal {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", test.sentence, "/", test.exp_greedy, "/", test.exp_optimal, "----->",
+				cmds_greedy, "/", cmds_optimal)
+		}
+	}
+
+	log.Println("Results (successes/total):", successes, "/", len(tests))
+}
+
+func testDetectionScores() {
+	log.Println("Running detection scores tests...")
+
+	var detector *ACD.Detector = ACD.NewDetector()
+	if err := detector.ReloadCmdsArray("1||0||toggle||||lamp\\2||" + ACD.CMDi_TYPE_TURN_ONFF + "||switch||||lamp\\" +
+		"3||0||open||||[big] door"); err != nil {
+		log.Println("PROBLEM DETECTED: the commands were not loaded -->", err)
+
+		return
+	}
+
+	var detect = func(detector *ACD.Detector, sentence string, options ACD.DetectOptions) []ACD.Detection {
+		var result ACD.DetectionResult
+		var err error
+		if detector != nil {
+			result, err = detector.Detect(sentence, options)
+		} else {
+			result, err = ACD.Detect(sentence, options)
+		}
+		if err != nil {
+			log.Println("PROBLEM DETECTED:", sentence, "----->", err)
+
+			return nil
+		}
+
+		return result.Detections
+	}
+	var score = func(detector *ACD.Detector, sentence string, prev_cmd_context ACD.CmdContext) float64 {
+		var detections []ACD.Detection = detect(detector, sentence, ACD.DetectOptions{
+			Invalidate_detec_words: true,
+			Prev_cmd_context:       prev_cmd_context,
+		})
+		if len(detections) != 1 {
+			log.Println("PROBLEM DETECTED:", sentence, "----->", detections)
+
+			return -1
+		}
+
+		return detections[0].Score
+	}
+
+	var tests = []struct {
+		description string
+		higher      float64
+		lower       float64
+	}{
+		{"manual main word vs type trigger word", score(detector, "switch on the lamp", ACD.CmdContext{}),
+			score(detector, "turn on the lamp", ACD.CmdContext{})},
+		{"words together vs apart", score(detector, "toggle lamp", ACD.CmdContext{}),
+			score(detector, "toggle the old rusty lamp", ACD.CmdContext{})},
+		{"optional group found vs missing", score(detector, "open big door", ACD.CmdContext{}),
+			score(detector, "open door", ACD.CmdContext{})},
+		{"words said vs put by the NLP analysis", score(nil, "turn on the wifi", ACD.CmdContext{}),
+			score(nil, "turn it on", ACD.CmdContext{Last_name: "wifi"})},
+	}
+
+	var successes int = 0
+	for _, test := range tests {
+		if test.higher > test.lower && test.lower > 0 && test.higher <= 1 {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", test.description, "----->", test.higher, "/", test.lower)
+		}
+	}
+
+	// Warnings are always certain
+	var num_tests int = len(tests) + 3
+	var detections []ACD.Detection = detect(nil, "turn it on", ACD.DetectOptions{})
+	if len(detections) == 1 && detections[0].Warning == ACD.WARNING_WHATS_IT && detections[0].Score == 1 {
+		successes++
+	} else {
+		log.Println("PROBLEM DETECTED: warning score ----->", detections)
+	}
+
+	// The confirmation threshold
+	for _, threshold := range []float64{0, 1.01} {
+		detections = detect(detector, "turn on the lamp", ACD.DetectOptions{Confirmation_threshold: threshold})
+		if len(detections) == 1 && detections[0].Needs_confirmation == (threshold > detections[0].Score) {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED: confirmation threshold", threshold, "----->", detections)
+		}
+	}
+
+	log.Println("Results (successes/total):", successes, "/", num_tests)
+}
+
+func testDetectionTrace() {
+	log.Println("Running detection trace tests...")
+
+	var trace = func(sentence string, options ACD.DetectOptions) *ACD.DetectionTrace {
+		result, err := ACD.Detect(sentence, options)
+		if err != nil {
+			log.Println("PROBLEM DETECTED:", sentence, "----->", err)
+
+			return nil
+		}
+
+		return result.Trace
+	}
+
+	var successes int = 0
+	var num_tests int = 0
+	var check = func(description string, ok bool, trace *ACD.DetectionTrace) {
+		num_tests++
+		if ok {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", description, "----->\n"+trace.String())
+		}
+	}
+
+	// Only collected when asked for
+	var no_trace *ACD.DetectionTrace = trace("turn on the wifi", ACD.DetectOptions{})
+	num_tests++
+	if no_trace == nil {
+		successes++
+	} else {
+		log.Println("PROBLEM DETECTED: trace collected without the option")
+	}
+
+	var options ACD.DetectOptions = ACD.DetectOptions{Invalidate_detec_words: true, Trace: true}
+
+	var trace_it *ACD.DetectionTrace = trace("turn it on", ACD.DetectOptions{
+		Invalidate_detec_words: true,
+		Trace:                  true,
+		Prev_cmd_context:       ACD.CmdContext{Last_name: "wifi"},
+	})
+	if trace_it != nil {
+		check("corrected sentence", strings.Join(trace_it.Corrected_sentence, " ") == "turn it on", trace_it)
+		check("POS tags", len(trace_it.Pos_tags) == 3 && trace_it.Pos_tags[0].Tag == "VB", trace_it)
+		check("it substitution", len(trace_it.Substitutions) == 1 && trace_it.Substitutions[0].Word == "it" &&
+			strings.Join(trace_it.Substitutions[0].Replacement, " ") == "wifi", trace_it)
+		check("detection sentence", strings.Join(trace_it.Detection_sentence, " ") == "turn wifi on", trace_it)
+	}
+
+	var trace_reboot *ACD.DetectionTrace = trace("fast reboot the phone", options)
+	if trace_reboot != nil {
+		var main_words []string = nil
+		for _, main_word := range trace_reboot.Main_words {
+			main_words = append(main_words, main_word.Main_word)
+		}
+		// "reboot" is taken by the "fast" detection, so it's not checked as a main word anymore.
+		check("main words", strings.Join(main_words, " ") == "fast", trace_reboot)
+		var conditions_found int = 0
+		for _, condition := range trace_reboot.Conditions {
+			if condition.All_found {
+				conditions_found++
+			}
+		}
+		check("conditions", len(trace_reboot.Conditions) > 0 && conditions_found > 0, trace_reboot)
+		check("return conditions", len(trace_reboot.Ret_cond_checks) > 0 &&
+			trace_reboot.Ret_cond_checks[0].Cmd_id == 14 && trace_reboot.Ret_cond_checks[0].Main_word == "fast" &&
+			trace_reboot.Ret_cond_checks[0].Condition == 0, trace_reboot)
+	}
+
+	var trace_dont *ACD.DetectionTrace = trace("turn on the wifi and the bluetooth. no, don't turn on the wifi",
+		options)
+	if trace_dont != nil {
+		var and_replaced bool = false
+		for _, substitution := range trace_dont.Substitutions {
+			if substitution.Word == "and" && substitution.Replacement != nil {
+				and_replaced = true
+			}
+		}
+		check("and substitution", and_replaced, trace_dont)
+		var filtered []string = nil
+		for _, filtered_cmd := range trace_dont.Filtered {
+			filtered = append(filtered, strconv.Itoa(filtered_cmd.Cmd_id))
+		}
+		check("filtered commands", strings.Join(filtered, " ") == "-1 4 4", trace_dont)
+		check("text", strings.Contains(trace_dont.String(), "Filtered:\n  [1] \"don't\": "), trace_dont)
+	}
+
+	log.Println("Results (successes/total):", successes, "/", num_tests)
+}
+
+func testDetectionSpans() {
+	log.Println("Running detection spans tests...")
+
+	var tests = []struct {
+		sentence  string
+		last_name string
+		// The text of the spans of each detection, as "main word|word1,word2"
+		exp_spans []string
+	}{
+		{"turn on the wi-fi please", "", []string{"turn|on,wi-fi"}},
+		// Corrected words have the span of the words corrected
+		{"what is the time", "", []string{"what is|time"}},
+		{"please do a shutdown of the phone", "", []string{"shutdown|shutdown,phone"}},
+		// Words put by the NLP analysis have the span of the "it" or "and"
+		{"turn it on", "wifi", []string{"turn|it,on"}},
+		{"turn on the wifi and the bluetooth", "", []string{"turn|on,wifi", "and|and,bluetooth"}},
+		{"turn it on", "", []string{"it|"}},
+	}
+
+	var successes int = 0
+	for _, test := range tests {
+		result, err := ACD.Detect(test.sentence, ACD.DetectOptions{
+			Invalidate_detec_words: true,
+			Prev_cmd_context:       ACD.CmdContext{Last_name: test.last_name},
+		})
+		if err != nil {
+			log.Println("PROBLEM DETECTED:", test.sentence, "----->", err)
+
+			continue
+		}
+
+		var spans []string = nil
+		for _, detection := range result.Detections {
+			var words []string = nil
+			for _, span := range detection.Words_spans {
+				words = append(words, test.sentence[span.Start:span.End])
+			}
+			spans = append(spans, test.sentence[detection.Main_word_span.Start:detection.Main_word_span.End]+"|"+
+				strings.Join(words, ","))
+		}
+		if strings.Join(spans, " / ") == strings.Join(test.exp_spans, " / ") {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", test.sentence, "/", test.exp_spans, "----->", spans)
+		}
+	}
+
+	log.Println("Results (successes/total):", successes, "/", len(tests))
+}
+
+func testUnconsumedSegments() {
+	log.Println("Running unconsumed segments tests...")
+
+	var tests = []struct {
+		sentence     string
+		exp_segments []string
+	}{
+		{"turn on the wifi and tell me a joke", []string{"tell me a joke"}},
+		{"tell me a joke", []string{"tell me a joke"}},
+		{"turn on the wifi", nil},
+		{"please turn on the wifi now", []string{"please", "now"}},
+		{"i want pizza then turn on the wifi and the bluetooth and also some music",
+			[]string{"i want pizza then", "also some music"}},
+		// The commands deleted by a "don't" were understood
+		{"turn on the wifi no don't turn on the wifi", []string{"no"}},
+	}
+
+	var successes int = 0
+	for _, test := range tests {
+		result, err := ACD.Detect(test.sentence, ACD.DetectOptions{Invalidate_detec_words: true})
+		if err != nil {
+			log.Println("PROBLEM DETECTED:", test.sentence, "----->", err)
+
+			continue
+		}
+
+		var segments []string = nil
+		for _, segment := range result.Unconsumed {
+			if segment.Text != test.sentence[segment.Span.Start:segment.Span.End] {
+				segments = append(segments, "(wrong span)")
+			}
+			segments = append(segments, segment.Text)
+		}
+		if strings.Join(segments, " / ") == strings.Join(test.exp_segments, " / ") {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", test.sentence, "/", test.exp_segments, "----->", segments)
+		}
+	}
+
+	log.Println("Results (successes/total):", successes, "/", len(tests))
+}
+
+func testAmbiguities() {
+	log.Println("Running ambiguities tests...")
+
+	var detector *ACD.Detector = ACD.NewDetector()
+	if err := detector.ReloadCmdsArray("1||0||stop||||call\\2||0||stop||||call\\3||0||open||||door|window\\" +
+		"4||0||close||||door|front door"); err != nil {
+		log.Println("PROBLEM DETECTED: the commands were not loaded -->", err)
+
+		return
+	}
+
+	var tests = []struct {
+		sentence             string
+		optimal_segmentation bool
+		report_ambiguities   bool
+		// The candidates of each detection, as "cmd1 (word1 word2) / cmd2 (...)"
+		exp_candidates []string
+	}{
+		// 2 commands with the same main word and words
+		{"stop the call", false, true, []string{"1.00001 (call) / 2.00001 (call)"}},
+		{"stop the call", true, true, []string{"1.00001 (call) / 2.00001 (call)"}},
+		{"stop the call", false, false, []string{""}},
+		// 2 conditions of the same command with as many words found
+		{"open the door or the window", false, true, []string{"3.00001 (door) / 3.00002 (window)"}},
+		{"open the door", false, true, []string{""}},
+		// The condition with more words found is not tied with the other
+		{"close the front door", false, true, []string{""}},
+	}
+
+	var successes int = 0
+	for _, test := range tests {
+		result, err := detector.Detect(test.sentence, ACD.DetectOptions{
+			Invalidate_detec_words: true,
+			Optimal_segmentation:   test.optimal_segmentation,
+			Report_ambiguities:     test.report_ambiguities,
+		})
+		if err != nil {
+			log.Println("PROBLEM DETECTED:", test.sentence, "----->", err)
+
+			continue
+		}
+
+		var detections_candidates []string = nil
+		for _, detection := range result.Detections {
+			var candidates []string = nil
+			for _, candidate := range detection.Candidates {
+				var candidate_detection ACD.Detection = ACD.Detection{
+					Cmd_id:        candidate.Cmd_id,
+					Sub_cmd_index: candidate.Sub_cmd_index,
+				}
+				candidates = append(candidates, candidate_detection.String()+" ("+strings.Join(candidate.Words, " ")+")")
+			}
+			detections_candidates = append(detections_candidates, strings.Join(candidates, " / "))
+		}
+		if strings.Join(detections_candidates, ", ") == strings.Join(test.exp_candidates, ", ") {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", test.sentence, "/", test.exp_candidates, "----->",
+				detections_candidates)
+		}
+	}
+
+	log.Println("Results (successes/total):", successes, "/", len(tests))
+}
+
+func testNearMisses() {
+	log.Println("Running near misses tests...")
+
+	var detector *ACD.Detector = ACD.NewDetector()
+	if err := detector.ReloadCmdsArray("1||0||turn||||on/off lamp\\2||0||record||||[rear] video|audio"); err != nil {
+		log.Println("PROBLEM DETECTED: the commands were not loaded -->", err)
+
+		return
+	}
+
+	var tests = []struct {
+		detector             *ACD.Detector
+		sentence             string
+		optimal_segmentation bool
+		report_near_misses   bool
+		// Each near miss as "cmd_id: variation (words found) [missing groups] / variation ..."
+		exp_near_misses []string
+	}{
+		{detector, "turn on the", false, true, []string{"1: 0 (on) [[lamp]]"}},
+		{detector, "turn on the", true, true, []string{"1: 0 (on) [[lamp]]"}},
+		{detector, "turn on the", false, false, nil},
+		{detector, "turn on the lamp", false, true, nil},
+		{detector, "record", false, true, []string{"2: 0 () [[video]] / 1 () [[audio]]"}},
+		// Only of the main words from which nothing was detected
+		{detector, "turn on the lamp and record", false, true, []string{"2: 0 () [[video]] / 1 () [[audio]]"}},
+		{nil, "turn off the", false, true, []string{"1: 1 (off) [[flashlight lantern]]", "4: 1 (off) [[wifi]]",
+			"5: 1 (off) [[mobile] [data]]", "6: 1 (off) [[bluetooth]]", "10: 1 (off) [[speaker speakers]]",
+			"11: 1 (off) [[airplane] [mode]]", "19: 1 (off) [[power battery] [saver]]"}},
+	}
+
+	var successes int = 0
+	for _, test := range tests {
+		var options ACD.DetectOptions = ACD.DetectOptions{
+			Invalidate_detec_words: true,
+			Optimal_segmentation:   test.optimal_segmentation,
+			Report_near_misses:     test.report_near_misses,
+		}
+		var result ACD.DetectionResult
+		var err error
+		if test.detector != nil {
+			result, err = test.detector.Detect(test.sentence, options)
+		} else {
+			result, err = ACD.Detect(test.sentence, options)
+		}
+		if err != nil {
+			log.Println("PROBLEM DETECTED:", test.sentence, "----->", err)
+
+			continue
+		}
+
+		var near_misses []string = nil
+		for _, near_miss := range result.Near_misses {
+			var variations []string = nil
+			for _, variation := range near_miss.Variations {
+				variations = append(variations, fmt.Sprint(variation.Sub_cmd_index, " (",
+					strings.Join(variation.Words, " "), ") ", variation.Missing_groups))
+			}
+			near_misses = append(near_misses, strconv.Itoa(near_miss.Cmd_id)+": "+strings.Join(variations, " / "))
+		}
+		if strings.Join(near_misses, ", ") == strings.Join(test.exp_near_misses, ", ") {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", test.sentence, "/", test.exp_near_misses, "----->", near_misses)
+		}
+	}
+
+	log.Println("Results (successes/total):", successes, "/", len(tests))
+}
+
+func testTaggers(commands_str string) {
+	log.Println("Running taggers tests...")
+
+	var lexicon_detector *ACD.Detector = ACD.NewDetector()
+	var custom_detector *ACD.Detector = ACD.NewDetector()
+	for _, detector := range []*ACD.Detector{lexicon_detector, custom_detector} {
+		if err := detector.ReloadCmdsArray(commands_str); err != nil {
+			log.Println("PROBLEM DETECTED: the commands were not loaded -->", err)
+
+			return
+		}
+	}
+	lexicon_detector.UseLexiconTagger()
+	custom_detector.SetTagger(mapTagger(nil))
+
+	var successes int = 0
+	var total int = 0
+	var check = func(ok bool, problem ...any) {
+		total++
+		if ok {
+			successes++
+		} else {
+			log.Println(append([]any{"PROBLEM DETECTED:"}, problem...)...)
+		}
+	}
+
+	// The tags of the lexicon tagger, with the words of the commands
+	var tags []string = nil
+	for _, token := range ACD.NewLexiconTagger([]string{"turn", "power", "next"}, []string{"power", "saver", "song"}).
+		Tag("turn on power saver and power it off and the next song") {
+		tags = append(tags, token.Tag)
+	}
+	const exp_tags string = "VB IN NN NN CC VB NN IN CC DT JJ NN"
+	check(strings.Join(tags, " ") == exp_tags, "lexicon tags /", exp_tags, "----->", tags)
+
+	// The lexicon tagger must detect the commands of all the detection tests (the meanings of the "it"s and "and"s may
+	// differ from the Prose one's, as they depend on the tags)
+	for _, test := range commands_tests {
+		var output string = lexicon_detector.MainInternal(test.sentence, test.remove_repet_cmds,
+			test.invalidate_detec_words, test.prev_cmd_info)
+		var detected_commands string = output[strings.Index(output, ACD.INFO_CMDS_SEPARATOR)+
+			len(ACD.INFO_CMDS_SEPARATOR):]
+		check(detected_commands == test.exp_cmd_list, test.sentence, "/", test.exp_cmd_list, "----->", output)
+	}
+
+	// The lexicon tagger follows the commands loaded
+	if err := lexicon_detector.AddUpdateCmd("99||0||blink||||lamp"); err != nil {
+		log.Println("PROBLEM DETECTED: the command was not added -->", err)
+	}
+	var cmds string = detectedCmds(lexicon_detector, "blink the lamp and the flashlight")
+	check(cmds == "99.00001", "lexicon after adding a command / 99.00001 ----->", cmds)
+
+	// A custom tagger which sees only names: no "and" is seen as an action, so only the first command is detected
+	cmds = detectedCmds(custom_detector, "turn on the wifi and the bluetooth")
+	check(cmds == "4.00001", "custom tagger / 4.00001 ----->", cmds)
+
+	// And the trace has the tags of the tagger used
+	result, err := lexicon_detector.Detect("turn it on", ACD.DetectOptions{Trace: true})
+	check(err == nil && len(result.Trace.Pos_tags) == 3 && result.Trace.Pos_tags[0].Tag == "VB",
+		"lexicon trace tags ----->", err, result.Trace)
+
+	log.Println("Results (successes/total):", successes, "/", total)
+}
+
+// mapTagger is a Tagger that tags the words with the tags on the map, and all the others as names.
+type mapTagger map[string]string
+
+func (tagger mapTagger) Tag(sentence_str string) []ACD.TaggedToken {
+	var tokens []ACD.TaggedToken = nil
+	for _, word := range strings.Split(sentence_str, " ") {
+		var tag string = "NN"
+		if map_tag, ok := tagger[word]; ok {
+			tag = map_tag
+		}
+		tokens = append(tokens, ACD.TaggedToken{Text: word, Tag: tag})
+	}
+
+	return tokens
+}
+
+func testTagOverrides() {
+	log.Println("Running tag overrides tests...")
+
+	var successes int = 0
+	var total int = 0
+	var check = func(ok bool, problem ...any) {
+		total++
+		if ok {
+			successes++
+		} else {
+			log.Println(append([]any{"PROBLEM DETECTED:"}, problem...)...)
+		}
+	}
+
+	// "power" is a main word of the 2nd command and a name on the 3rd, so the commands don't give it a tag
+	var detector *ACD.Detector = ACD.NewDetector()
+	if err := detector.ReloadCmdsArray("1||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||wifi\\2||" +
+		ACD.CMDi_TYPE_SHUT_DOWN + "||||||phone\\3||" + ACD.CMDi_TYPE_TURN_ONFF + "||||||power saver\\4||" +
+		ACD.CMDi_TYPE_NONE + "||play next||||song\\6||" + ACD.CMDi_TYPE_STOP + "||||||recording audio"); err != nil {
+		log.Println("PROBLEM DETECTED: the commands were not loaded -->", err)
+
+		return
+	}
+	detector.SetTagger(mapTagger{"on": "IN", "the": "DT", "down": "RP", "next": "JJ", "song": "VB", "recording": "VBG"})
+
+	var posTags = func(sentence string) string {
+		result, err := detector.Detect(sentence, ACD.DetectOptions{Trace: true})
+		if err != nil {
+			return err.Error()
+		}
+		var tags []string = nil
+		for _, pos_tag := range result.Trace.Pos_tags {
+			tags = append(tags, pos_tag.Tag)
+		}
+
+		return strings.Join(tags, " ")
+	}
+
+	var tests = []struct {
+		tag_overrides_str string
+		sentence          string
+		exp_tags          string
+	}{
+		// Main words as verbs and the words of the conditions as names, but only over names and verbs ("next" stays
+		// an adjective)
+		{"", "turn on the wifi", "VB IN DT NN"},
+		{"", "shut down the phone", "VB RP DT NN"},
+		{"", "play the next song", "VB DT JJ NN"},
+		{"", "turn on power saver", "VB IN NN NN"},
+		// But not over the inflected verbs ("recording" is a name of a command, but a verb here)
+		{"", "stop recording the song", "VB VBG DT NN"},
+		// The ones of the app go over all the others
+		{"power:VB wifi:JJ next:VB", "turn on power saver", "VB IN VB NN"},
+		{"power:VB wifi:JJ next:VB", "turn on the wifi", "VB IN DT JJ"},
+		{"power:VB wifi:JJ next:VB", "play the next song", "VB DT VB NN"},
+		// And can be removed
+		{"", "turn on the wifi", "VB IN DT NN"},
+	}
+	for _, test := range tests {
+		if err := detector.SetTagOverridesStr(test.tag_overrides_str); err != nil {
+			log.Println("PROBLEM DETECTED: the tag overrides were not set -->", err)
+		}
+		var tags string = posTags(test.sentence)
+		check(tags == test.exp_tags, test.tag_overrides_str, "/", test.sentence, "/", test.exp_tags, "----->", tags)
+	}
+
+	// The new commands give the tags of their words, with no need to change the library
+	if err := detector.AddUpdateCmd("5||" + ACD.CMDi_TYPE_NONE + "||water||||plants"); err != nil {
+		log.Println("PROBLEM DETECTED: the command was not added -->", err)
+	}
+	detector.SetTagger(mapTagger{"water": "NN", "plants": "VBZ", "the": "DT"})
+	var tags string = posTags("water the plants")
+	check(tags == "VB DT NN", "new command / VB DT NN ----->", tags)
+
+	// Invalid overrides are not set
+	check(detector.SetTagOverrides(map[string]string{"": "VB", "water": ""}) != nil, "invalid tag overrides accepted")
+	check(detector.SetTagOverridesStr("water:VB plants") != nil, "invalid tag overrides string accepted")
+	check(detector.SetTagOverrides(map[string]string{"water": "JJ"}) == nil && posTags("water the plants") == "JJ DT NN",
+		"tag overrides not set after invalid ones")
+
+	log.Println("Results (successes/total):", successes, "/", total)
+}
+
+func testNLPAlignment() {
+	log.Println("Running NLP alignment property tests...")
+
+	// Words that the tagger splits in more than one token ("wifi." --> "wifi" and "."; "phone's" --> "phone" and "'s")
+	// mixed with the words which the analysis replaces or deletes: "it"s, "and"s, the plural pronouns ("them", "they",
+	// "both", "all of them") and "one"s.
+	var vocabulary = []string{"turn", "on", "off", "the", "wifi", "bluetooth", "and", "it", "then", "reboot", "a", "rear",
+		"frontal", "picture", "phone", "wifi.", "phone's", "i'll", "picture,", "off!", "\"wifi\"", "...", "take",
+		"shut", "down", "airplane", "mode", "them", "they", "both", "all", "of", "one", "next"}
+	// The words the analysis may replace or delete (the ones of "all of them" included)
+	var replaceable_words = map[string]bool{"it": true, "and": true, "them": true, "they": true, "both": true,
+		"all": true, "of": true, "one": true}
+	var random *rand.Rand = rand.New(rand.NewSource(590))
+	var randomSentence = func() string {
+		var words []string = nil
+		for i := random.Intn(12); i >= 0; i-- {
+			words = append(words, vocabulary[random.Intn(len(vocabulary))])
+		}
+
+		return strings.Join(words, " ")
+	}
+	var analyze = func(sentence string, tagger ACD.Tagger) (words []string, tags []string, spans []ACD.TextSpan,
+		err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		words, tags, spans = ACD.NLPAnalysisInternal(sentence, tagger)
+
+		return words, tags, spans, nil
+	}
+
+	const num_sentences int = 500
+	var successes int = 0
+	for i := 0; i < num_sentences; i++ {
+		var sentence string = randomSentence()
+		words, tags, spans, err := analyze(sentence, splittingTagger{})
+		if err != nil || len(words) != len(tags) || len(words) != len(spans) {
+			log.Println("PROBLEM DETECTED:", sentence, "----->", err, words, tags, spans)
+
+			continue
+		}
+
+		// The words the tagger tagged (not put there by the analysis) must be the ones of the sentence, in order, less
+		// only replaceable words - each with its own tag and coming from its own place on the sentence. The words put
+		// there by the analysis must come from replaceable words.
+		var tagged_words []string = nil
+		var problems []string = nil
+		for word_index, word := range words {
+			var span ACD.TextSpan = spans[word_index]
+			if span.Start < 0 || span.End > len(sentence) || span.Start > span.End {
+				problems = append(problems, word+"/bad span")
+
+				continue
+			}
+			var span_text string = sentence[span.Start:span.End]
+			if strings.Contains(tags[word_index], "-") {
+				tagged_words = append(tagged_words, word)
+				if tags[word_index] != splittingTagger.wordTag(splittingTagger{}, word) {
+					problems = append(problems, word+"/"+tags[word_index])
+				}
+				if span_text != word {
+					problems = append(problems, word+"/from "+span_text)
+				}
+			} else {
+				for _, span_word := range strings.Split(span_text, " ") {
+					if !replaceable_words[span_word] {
+						problems = append(problems, word+"/from "+span_text)
+					}
+				}
+			}
+		}
+		var tagged_index int = 0
+		for _, word := range strings.Split(sentence, " ") {
+			if tagged_index < len(tagged_words) && tagged_words[tagged_index] == word {
+				tagged_index++
+			} else if !replaceable_words[word] {
+				problems = append(problems, word+"/missing")
+			}
+		}
+		if tagged_index != len(tagged_words) || problems != nil {
+			log.Println("PROBLEM DETECTED:", sentence, "----->", words, tags, problems)
+
+			continue
+		}
+		successes++
+	}
+
+	// And with Prose, which splits the words its own way (just a few sentences, as it's slow), never panicking - the
+	// first one used to.
+	var prose_sentences = []string{"turn on the wifi and the bluetooth and take a picture"}
+	for i := 0; i < 4; i++ {
+		prose_sentences = append(prose_sentences, randomSentence())
+	}
+	for _, sentence := range prose_sentences {
+		words, tags, spans, err := analyze(sentence, ACD.ProseTagger{})
+		if err != nil || len(words) != len(tags) || len(words) != len(spans) {
+			log.Println("PROBLEM DETECTED: Prose /", sentence, "----->", err, words, tags)
+
+			continue
+		}
+		successes++
+	}
+
+	log.Println("Results (successes/total):", successes, "/", num_sentences+len(prose_sentences))
+}
+
+// splittingTagger is a Tagger that splits the punctuation and the "'s" and "'ll" off the words into tokens of their own,
+// like Prose, and tags each token with a tag that has its text ("NN-wifi"), to know which token each tag came from.
+type splittingTagger struct{}
+
+func (splittingTagger) Tag(sentence_str string) []ACD.TaggedToken {
+	var tokens []ACD.TaggedToken = nil
+	for _, word := range strings.Split(sentence_str, " ") {
+		for _, piece := range splittingTagger.pieces(splittingTagger{}, word) {
+			tokens = append(tokens, ACD.TaggedToken{Text: piece, Tag: splittingTagger.pieceTag(splittingTagger{}, piece)})
+		}
+	}
+
+	return tokens
+}
+
+// pieces splits a word into its tokens.
+func (splittingTagger) pieces(word string) []string {
+	var pieces []string = nil
+	var start int = 0
+	for i, char := range word {
+		if strings.ContainsRune(".,!?\"", char) {
+			if start < i {
+				pieces = append(pieces, word[start:i])
+			}
+			pieces = append(pieces, string(char))
+			start = i + 1
+		} else if char == '\'' && start < i {
+			pieces = append(pieces, word[start:i])
+			start = i
+		}
+	}
+	if start < len(word) {
+		pieces = append(pieces, word[start:])
+	}
+
+	return pieces
+}
+
+// pieceTag gets the tag of a token.
+func (splittingTagger) pieceTag(piece string) string {
+	switch {
+	case strings.Trim(piece, ".,!?\"") == "":
+		return "SYM-" + piece
+	case piece == "turn" || piece == "reboot" || piece == "take" || piece == "shut":
+		return "VB-" + piece
+	case piece == "rear" || piece == "frontal" || piece == "next":
+		return "JJ-" + piece
+	case piece == "the" || piece == "a" || piece == "both" || piece == "all":
+		return "DT-" + piece
+	case piece == "them" || piece == "they":
+		return "PRP-" + piece
+	case piece == "of" || piece == "on":
+		return "IN-" + piece
+	default:
+		return "NN-" + piece
+	}
+}
+
+// wordTag gets the tag a word must have: the one of its first token with letters, or of its first token if none has.
+func (tagger splittingTagger) wordTag(word string) string {
+	var pieces []string = tagger.pieces(word)
+	for _, piece := range pieces {
+		if !strings.HasPrefix(tagger.pieceTag(piece), "SYM-") {
+			return tagger.pieceTag(piece)
+		}
+	}
+
+	return tagger.pieceTag(pieces[0])
+}
+
+func testPluralPronouns() {
+	log.Println("Running plural pronouns tests...")
+
+	var tests = []struct {
+		sentence      string
+		prev_cmd_info string
+		exp_output    string
+	}{
+		{"turn on the wifi and the bluetooth then turn them off", "|",
+			"bluetooth|turn off|wifi+bluetooth\\\\//4.00001, 6.00001, 4.00002, 6.00002"},
+		{"turn on the wifi and the bluetooth then turn off both", "|",
+			"bluetooth|turn off|wifi+bluetooth\\\\//4.00001, 6.00001, 4.00002, 6.00002"},
+		{"turn on the wifi and the bluetooth then turn all of them off", "|",
+			"bluetooth|turn off|wifi+bluetooth\\\\//4.00001, 6.00001, 4.00002, 6.00002"},
+		{"turn on the wifi and the airplane mode and the flashlight and now turn them off", "|",
+			"flashlight|turn off|wifi+airplane mode+flashlight\\\\//4.00001, 11.00001, 1.00001, 4.00002, 11.00002, 1.00002"},
+		// Through the previous command information
+		{"turn on the wifi and the bluetooth", "|", "bluetooth|turn on the|wifi+bluetooth\\\\//4.00001, 6.00001"},
+		{"now turn them off", "bluetooth|turn on the|wifi+bluetooth",
+			"bluetooth|turn off|wifi+bluetooth\\\\//4.00002, 6.00002"},
+		// Only the coordinated names: the last group of them, even with a single name after it, and none if the names
+		// were not coordinated
+		{"turn on the wifi and the bluetooth and turn off the airplane mode then turn them off", "|",
+			"airplane mode|turn off|wifi+bluetooth\\\\//4.00001, 6.00001, 11.00002, 4.00002, 6.00002"},
+		{"turn on the wifi then turn on the bluetooth and turn them off", "|",
+			"bluetooth|turn off|\\\\//4.00001, 6.00001, -10"},
+		// "they" with no action is not a command
+		{"they should be off", "bluetooth|turn on the|wifi+bluetooth", "bluetooth|be off|wifi+bluetooth\\\\//"},
+		{"turn on the wifi and the bluetooth they should be off", "|",
+			"bluetooth|be off|wifi+bluetooth\\\\//4.00001, 6.00001"},
+		// Each name only once
+		{"turn on the wifi and the bluetooth and the wifi", "|", "wifi|turn on the|wifi+bluetooth\\\\//4.00001, 6.00001, 4.00001"},
+		// Names with their adjectives
+		{"take a frontal picture and a rear picture", "|",
+			"picture|take a frontal|frontal picture+rear picture\\\\//15.00002, 15.00001"},
+		// "both" not as a pronoun
+		{"turn on both the wifi and the bluetooth", "|", "bluetooth|turn on both the|wifi+bluetooth\\\\//4.00001, 6.00001"},
+		// Nothing to refer to
+		{"turn them off", "|", "|turn off|\\\\//-10"},
+	}
+
+	var successes int = 0
+	for _, test := range tests {
+		var output string = ACD.MainInternal(test.sentence, false, true, test.prev_cmd_info)
+		if output == test.exp_output {
+			successes++
+		} else {
+			log.Println("PROBLEM DETECTED:", test.sentence, "/", test.exp_output, "----->", output)
+		}
+	}
+
+	log.Println("Results (successes/total):", successes, "/", len(tests))
+}
+
+/*
+detectedCmds gets only the detected commands from the output of Detector.MainInternal().
+
+-----------------------------------------------------------
+
+– Params:
+  - detector – the detector to use
+  - sentence – the sentence to detect the commands in
+
+– Returns:
+  - the detected commands, separated by ACD.CMDS_SEPARATOR
+*/
+func detectedCmds(detector *ACD.Detector, sentence string) string {
+	var output string = detector.MainInternal(sentence, false, true, "|")
+
+	return output[strings.Index(output, ACD.INFO_CMDS_SEPARATOR)+len(ACD.INFO_CMDS_SEPARATOR):]
+}
+
+/*
+cmdDefinitionErrors gets all the *ACD.CmdDefinitionError inside an error returned by the commands loading functions.
+
+-----------------------------------------------------------
+
+– Params:
+  - err – the error
+
+– Returns:
+  - the command definition errors found, in order
+*/
+func cmdDefinitionErrors(err error) []*ACD.CmdDefinitionError {
+	if err == nil {
+		return nil
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var cmd_errs []*ACD.CmdDefinitionError = nil
+		for _, sub_err := range joined.Unwrap() {
+			cmd_errs = append(cmd_errs, cmdDefinitionErrors(sub_err)...)
+		}
+
+		return cmd_errs
+	}
+
+	var cmd_err *ACD.CmdDefinitionError = nil
+	if errors.As(err, &cmd_err) {
+		return []*ACD.CmdDefinitionError{cmd_err}
+	}
+
+	return nil
+}
+
 // Tests of good functioning of the commands detector.
 // Only put commands here that have once worked, and so they must continue to work even after updates to the detection
 // engine.
@@ -86,7 +1557,7 @@ var commands_tests = [...]commandTestsInfo{
 		remove_repet_cmds:      false,
 		invalidate_detec_words: true,
 		prev_cmd_info:          "|",
-		exp_cmd_info:           "bluetooth|turn on|",
+		exp_cmd_info:           "bluetooth|turn on|wifi+bluetooth",
 	}, { // 4
 		sentence:               "turn wifi and on get the airplane mode on no don't turn the wifi on turn off airplane mode and turn the wifi on",
 		exp_cmd_list:           "11.00001, 11.00002, 4.00001",
@@ -107,14 +1578,14 @@ var commands_tests = [...]commandTestsInfo{
 		remove_repet_cmds:      false,
 		invalidate_detec_words: true,
 		prev_cmd_info:          "|",
-		exp_cmd_info:           "wifi|turn off|",
+		exp_cmd_info:           "wifi|turn off|airplane mode+wifi",
 	}, { // 7
 		sentence:               "turn wifi on and and the airplane mode and the flashlight",
 		exp_cmd_list:           "4.00001, 11.00001, 1.00001",
 		remove_repet_cmds:      false,
 		invalidate_detec_words: true,
 		prev_cmd_info:          "|",
-		exp_cmd_info:           "flashlight|turn on|",
+		exp_cmd_info:           "flashlight|turn on|wifi+airplane mode+flashlight",
 	}, { // 8
 		sentence:               "shut down the phone and then reboot it",
 		exp_cmd_list:           "13.00001, 14.00002",
@@ -170,20 +1641,69 @@ var commands_tests = [...]commandTestsInfo{
 		remove_repet_cmds:      false,
 		invalidate_detec_words: true,
 		prev_cmd_info:          "|",
-		exp_cmd_info:           "news|tell me the|",
+		exp_cmd_info:           "news|tell me the|weather+news",
 	}, { // 16
 		sentence:               "turn on the mobile data and the bluetooth never mind don't do it turn on the wifi",
 		exp_cmd_list:           "4.00001",
 		remove_repet_cmds:      false,
 		invalidate_detec_words: true,
 		prev_cmd_info:          "|",
-		exp_cmd_info:           "wifi|turn on the|",
+		exp_cmd_info:           "wifi|turn on the|mobile data+bluetooth",
 	}, { // 17
 		sentence:               "take a frontal picture and a rear picture",
 		exp_cmd_list:           "15.00002, 15.00001",
 		remove_repet_cmds:      false,
 		invalidate_detec_words: true,
 		prev_cmd_info:          "|",
-		exp_cmd_info:           "picture|NOT SURE|",
+		exp_cmd_info:           "picture|take a frontal|frontal picture+rear picture",
+	}, { // 18
+		sentence:               "take a frontal picture and a rear one",
+		exp_cmd_list:           "15.00002, 15.00001",
+		remove_repet_cmds:      false,
+		invalidate_detec_words: true,
+		prev_cmd_info:          "|",
+		exp_cmd_info:           "picture|take a frontal|",
+	}, { // 19
+		sentence:               "take a picture and a frontal one",
+		exp_cmd_list:           "15.00001, 15.00002",
+		remove_repet_cmds:      false,
+		invalidate_detec_words: true,
+		prev_cmd_info:          "|",
+		exp_cmd_info:           "picture|take a|",
+	}, { // 20
+		sentence:               "play the video and then stop that one",
+		exp_cmd_list:           "21.00001, 21.00003",
+		remove_repet_cmds:      false,
+		invalidate_detec_words: true,
+		prev_cmd_info:          "|",
+		exp_cmd_info:           "video|stop that|",
+	}, { // 21
+		sentence:               "stop recording it",
+		exp_cmd_list:           "-10",
+		remove_repet_cmds:      false,
+		invalidate_detec_words: true,
+		prev_cmd_info:          "|",
+		exp_cmd_info:           "|recording|",
+	}, { // 22
+		sentence:               "turn on the mobile data and the bluetooth then turn them off",
+		exp_cmd_list:           "5.00001, 6.00001, 5.00002, 6.00002",
+		remove_repet_cmds:      false,
+		invalidate_detec_words: true,
+		prev_cmd_info:          "|",
+		exp_cmd_info:           "bluetooth|turn off|mobile data+bluetooth",
+	}, { // 23
+		sentence:               "turn on the wifi",
+		exp_cmd_list:           "4.00001",
+		remove_repet_cmds:      false,
+		invalidate_detec_words: true,
+		prev_cmd_info:          "",
+		exp_cmd_info:           "wifi|turn on the|",
+	}, { // 24
+		sentence:               "turn it off",
+		exp_cmd_list:           "4.00002",
+		remove_repet_cmds:      false,
+		invalidate_detec_words: true,
+		prev_cmd_info:          "wifi",
+		exp_cmd_info:           "wifi|turn off|",
 	},
 }
@@ -7,6 +7,7 @@ go 1.20
 require (
 	github.com/Edw590/TryCatch-go v0.0.0-20240613213244-892990200ac0
 	github.com/jdkato/prose/v2 v2.0.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -71,7 +71,9 @@ func main() {
 
 	var commands = [...][]string{
 		// {command ID, types separated by "+", manual main words, return conditions for the main words, list of words
-		//  separated by "|" with optional words separated by "/"} - look at the examples below
+		//  separated by "|" with optional words separated by "/"} - look at the examples below (the groups can also be
+		//  optional with "[rear]", have digits with "#", and only be accepted in some positions with "on/off@0"; and the
+		//  main words can be phrases, like "good_night")
 		{CMD_TOGGLE_FLASHLIGHT, ACD.CMDi_TYPE_TURN_ONFF, "", "", "flashlight/lantern"},
 		{CMD_ASK_TIME, ACD.CMDi_TYPE_ASK, "", "", "time"},
 		{CMD_ASK_DATE, ACD.CMDi_TYPE_ASK, "", "", "date/day/month/year"},
@@ -110,7 +112,9 @@ func main() {
 
 	log.Println(commands_str)
 
-	ACD.ReloadCmdsArray(commands_str)
+	if err := ACD.ReloadCmdsArray(commands_str); err != nil {
+		log.Println(err)
+	}
 
 	arguments := os.Args
 	if len(arguments) > 1 {
@@ -121,9 +125,9 @@ func main() {
 	}
 	log.Println("")
 
-	var sentence_str string = "take a frontal picture and a rear picture"
+	var sentence_str string = "take a frontal picture and a rear one"
 	// TODO: None of these below work decently... Fix them.
-	//var sentence_str string = "take a frontal picture and a rear picture"
+	//var sentence_str string = "take a frontal and rear picture"
 
 	log.Println(sentence_str) // Just to also see it on the terminal (better than getting back here just to read it)
 	log.Println("To do: " + ACD.MainInternal(sentence_str, false, true, "|"))
@@ -132,4 +136,24 @@ func main() {
 
 	// Uncomment to test if the commands detection is still functioning well after modifications to the engine.
 	testCommandsDetection()
+	testCmdsEncoding()
+	testMultipleDetectors()
+	testCmdsDefinitions(commands_str)
+	testCmdsValidation(commands_str)
+	testConditionsOrder()
+	testCompactSyntax()
+	testSearchWindows()
+	testCmdTypes()
+	testMainWordsPhrases()
+	testOptimalSegmentation()
+	testDetectionScores()
+	testDetectionTrace()
+	testDetectionSpans()
+	testUnconsumedSegments()
+	testAmbiguities()
+	testNearMisses()
+	testTaggers(commands_str)
+	testTagOverrides()
+	testNLPAlignment()
+	testPluralPronouns()
 }